@@ -0,0 +1,188 @@
+package ARGS
+
+import (
+    "encoding/base64"
+    "encoding/json"
+    "fmt"
+    "regexp"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// Prefixes recognised by Parse. An argument without a prefix is passed as a string.
+//
+//  int::42                      int64
+//  float::1.5, double::1.5      float64
+//  bool::true                   bool
+//  null::                       NULL
+//  blob::<base64>               []byte
+//  date::2024-01-31             time.Time at 00:00 UTC
+//  datetime::<RFC3339>          time.Time
+//  decimal::12.3400             string with the exact digits, never float64
+//  uuid::<uuid>                 lowercase 8-4-4-4-12 string
+//  json::<document>             string holding a valid JSON document
+//  array::<JSON array>          PostgreSQL array literal, e.g. {1,2,3}
+//  named::<JSON object|array>   Named, the values of the :name/@name parameters
+
+var (
+    decimalPattern = regexp.MustCompile(`^[+-]?(?:\d+(?:\.\d*)?|\.\d+)(?:[eE][+-]?\d+)?$`)
+    uuidPattern    = regexp.MustCompile(`^[0-9a-fA-F]{8}-?[0-9a-fA-F]{4}-?[0-9a-fA-F]{4}-?[0-9a-fA-F]{4}-?[0-9a-fA-F]{12}$`)
+)
+
+// Named holds the JSON object, or the array of objects for a batch, that
+// binds the :name and @name parameters of a query. It is only honoured as the
+// single argument of a statement.
+type Named string
+
+// Parse turns the prefixed string arguments into driver values
+func Parse(args []string) ([]interface{}, error) {
+    var goArgs []interface{}
+
+    for _, arg := range args {
+        value, err := parseArg(arg)
+        if err != nil {
+            return nil, err
+        }
+        goArgs = append(goArgs, value)
+    }
+
+    return goArgs, nil
+}
+
+func parseArg(arg string) (interface{}, error) {
+    switch {
+    case strings.HasPrefix(arg, "int::"):
+        intVal, err := strconv.ParseInt(arg[5:], 10, 64)
+        if err != nil {
+            return nil, fmt.Errorf("Error parseando entero: %s", arg[5:])
+        }
+        return intVal, nil
+
+    case strings.HasPrefix(arg, "float::"), strings.HasPrefix(arg, "double::"):
+        prefixLen := 7
+        if strings.HasPrefix(arg, "double::") {
+            prefixLen = 8
+        }
+        floatVal, err := strconv.ParseFloat(arg[prefixLen:], 64)
+        if err != nil {
+            return nil, fmt.Errorf("Error parseando float: %s", arg[prefixLen:])
+        }
+        return floatVal, nil
+
+    case strings.HasPrefix(arg, "bool::"):
+        boolVal, err := strconv.ParseBool(arg[6:])
+        if err != nil {
+            return nil, fmt.Errorf("Error parseando booleano: %s", arg[6:])
+        }
+        return boolVal, nil
+
+    case strings.HasPrefix(arg, "null::"):
+        return nil, nil
+
+    case strings.HasPrefix(arg, "blob::"):
+        data, err := base64.StdEncoding.DecodeString(arg[6:])
+        if err != nil {
+            return nil, fmt.Errorf("Error decodificando blob: %v", err)
+        }
+        return data, nil
+
+    case strings.HasPrefix(arg, "date::"):
+        dateVal, err := time.Parse("2006-01-02", arg[6:])
+        if err != nil {
+            return nil, fmt.Errorf("Error parseando fecha: %s", arg[6:])
+        }
+        return dateVal, nil
+
+    case strings.HasPrefix(arg, "datetime::"):
+        timeVal, err := time.Parse(time.RFC3339Nano, arg[10:])
+        if err != nil {
+            return nil, fmt.Errorf("Error parseando fecha y hora: %s", arg[10:])
+        }
+        return timeVal, nil
+
+    case strings.HasPrefix(arg, "decimal::"):
+        // Se envía como texto para no perder precisión en DECIMAL/NUMERIC
+        if !decimalPattern.MatchString(arg[9:]) {
+            return nil, fmt.Errorf("Error parseando decimal: %s", arg[9:])
+        }
+        return arg[9:], nil
+
+    case strings.HasPrefix(arg, "uuid::"):
+        uuid := strings.TrimSuffix(strings.TrimPrefix(arg[6:], "{"), "}")
+        if !uuidPattern.MatchString(uuid) {
+            return nil, fmt.Errorf("Error parseando UUID: %s", arg[6:])
+        }
+        hex := strings.ToLower(strings.ReplaceAll(uuid, "-", ""))
+        return hex[0:8] + "-" + hex[8:12] + "-" + hex[12:16] + "-" + hex[16:20] + "-" + hex[20:], nil
+
+    case strings.HasPrefix(arg, "json::"):
+        if !json.Valid([]byte(arg[6:])) {
+            return nil, fmt.Errorf("Error parseando JSON: %s", arg[6:])
+        }
+        return arg[6:], nil
+
+    case strings.HasPrefix(arg, "named::"):
+        doc := strings.TrimSpace(arg[7:])
+        if doc == "" || (doc[0] != '{' && doc[0] != '[') || !json.Valid([]byte(doc)) {
+            return nil, fmt.Errorf("Error parseando parámetros con nombre: %s", arg[7:])
+        }
+        return Named(doc), nil
+
+    case strings.HasPrefix(arg, "array::"):
+        literal, err := arrayLiteral(arg[7:])
+        if err != nil {
+            return nil, fmt.Errorf("Error parseando array: %s", arg[7:])
+        }
+        return literal, nil
+    }
+
+    return arg, nil
+}
+
+// arrayLiteral converts a JSON array into the PostgreSQL array input syntax.
+// Nested arrays become multidimensional arrays and null becomes NULL.
+func arrayLiteral(s string) (string, error) {
+    decoder := json.NewDecoder(strings.NewReader(s))
+    decoder.UseNumber()
+
+    var items []interface{}
+    if err := decoder.Decode(&items); err != nil {
+        return "", err
+    }
+
+    var sb strings.Builder
+    if err := writeArray(&sb, items); err != nil {
+        return "", err
+    }
+    return sb.String(), nil
+}
+
+func writeArray(sb *strings.Builder, items []interface{}) error {
+    sb.WriteString("{")
+    for i, item := range items {
+        if i > 0 {
+            sb.WriteString(",")
+        }
+        switch v := item.(type) {
+        case nil:
+            sb.WriteString("NULL")
+        case json.Number:
+            sb.WriteString(v.String())
+        case bool:
+            sb.WriteString(strconv.FormatBool(v))
+        case string:
+            sb.WriteString(`"`)
+            sb.WriteString(strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(v))
+            sb.WriteString(`"`)
+        case []interface{}:
+            if err := writeArray(sb, v); err != nil {
+                return err
+            }
+        default:
+            return fmt.Errorf("tipo no soportado en array: %T", v)
+        }
+    }
+    sb.WriteString("}")
+    return nil
+}
@@ -0,0 +1,78 @@
+package ARGS
+
+import (
+    "reflect"
+    "testing"
+    "time"
+)
+
+func TestParse(t *testing.T) {
+    tests := []struct {
+        arg     string
+        want    interface{}
+        wantErr bool
+    }{
+        {arg: "plain text", want: "plain text"},
+        {arg: "", want: ""},
+        {arg: "int::42", want: int64(42)},
+        {arg: "int::-7", want: int64(-7)},
+        {arg: "int::4.2", wantErr: true},
+        {arg: "float::1.5", want: 1.5},
+        {arg: "double::-0.25", want: -0.25},
+        {arg: "float::x", wantErr: true},
+        {arg: "bool::true", want: true},
+        {arg: "bool::0", want: false},
+        {arg: "bool::maybe", wantErr: true},
+        {arg: "null::", want: nil},
+        {arg: "blob::AP8=", want: []byte{0x00, 0xff}},
+        {arg: "blob::***", wantErr: true},
+        {arg: "date::2024-01-31", want: time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)},
+        {arg: "date::31/01/2024", wantErr: true},
+        {arg: "datetime::2024-01-31T10:20:30.5Z", want: time.Date(2024, 1, 31, 10, 20, 30, 500000000, time.UTC)},
+        {arg: "datetime::2024-01-31 10:20:30", wantErr: true},
+        {arg: "decimal::12.3400", want: "12.3400"},
+        {arg: "decimal::-.5e3", want: "-.5e3"},
+        {arg: "decimal::1,5", wantErr: true},
+        {arg: "uuid::{0E984725-C51C-4BF4-9960-E1C80E27ABA0}", want: "0e984725-c51c-4bf4-9960-e1c80e27aba0"},
+        {arg: "uuid::0e984725c51c4bf49960e1c80e27aba0", want: "0e984725-c51c-4bf4-9960-e1c80e27aba0"},
+        {arg: "uuid::0e984725", wantErr: true},
+        {arg: `json::{"a":[1,2]}`, want: `{"a":[1,2]}`},
+        {arg: `json::{"a":`, wantErr: true},
+        {arg: `array::[1,2,3]`, want: "{1,2,3}"},
+        {arg: `array::[["a","b\"c"],[null,"d\\e"]]`, want: `{{"a","b\"c"},{NULL,"d\\e"}}`},
+        {arg: `array::[{"a":1}]`, wantErr: true},
+        {arg: `array::1`, wantErr: true},
+        {arg: `named::{"a":1}`, want: Named(`{"a":1}`)},
+        {arg: `named:: [{"a":1}]`, want: Named(`[{"a":1}]`)},
+        {arg: `named::"a"`, wantErr: true},
+        {arg: `named::`, wantErr: true},
+    }
+
+    for _, tt := range tests {
+        got, err := Parse([]string{tt.arg})
+        if tt.wantErr {
+            if err == nil {
+                t.Errorf("Parse(%q) = %#v, want an error", tt.arg, got)
+            }
+            continue
+        }
+        if err != nil {
+            t.Errorf("Parse(%q) failed: %v", tt.arg, err)
+            continue
+        }
+        if !reflect.DeepEqual(got[0], tt.want) {
+            t.Errorf("Parse(%q) = %#v, want %#v", tt.arg, got[0], tt.want)
+        }
+    }
+}
+
+func TestParseKeepsOrder(t *testing.T) {
+    got, err := Parse([]string{"int::1", "a", "null::", "bool::true"})
+    if err != nil {
+        t.Fatal(err)
+    }
+    want := []interface{}{int64(1), "a", nil, true}
+    if !reflect.DeepEqual(got, want) {
+        t.Errorf("Parse = %#v, want %#v", got, want)
+    }
+}
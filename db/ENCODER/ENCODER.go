@@ -0,0 +1,219 @@
+package ENCODER
+
+import (
+    "bytes"
+    "database/sql"
+    "encoding/base64"
+    "encoding/json"
+    "reflect"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// Mode selects how column values are written into the result JSON
+type Mode int
+
+const (
+    // Strings writes every non-null value as a JSON string (original shape)
+    Strings Mode = iota
+    // Typed writes numbers, booleans, RFC3339 timestamps and nested JSON
+    Typed
+)
+
+// Layouts that drivers return for temporal columns when they are read as text
+var timeLayouts = []string{
+    time.RFC3339Nano,
+    "2006-01-02 15:04:05.999999999-07:00",
+    "2006-01-02 15:04:05.999999999Z07:00",
+    "2006-01-02 15:04:05.999999999 -0700 MST",
+    "2006-01-02 15:04:05.999999999",
+    "2006-01-02T15:04:05.999999999",
+    "2006-01-02",
+}
+
+// WriteRow writes one scanned row (values holding *sql.RawBytes) as a JSON object
+func WriteRow(buf *bytes.Buffer, mode Mode, columns []string, colTypes []*sql.ColumnType, values []interface{}, isBinary func(string) bool) {
+    buf.WriteString("{")
+    for i := range values {
+        if i > 0 {
+            buf.WriteString(",")
+        }
+        WriteString(buf, columns[i])
+        buf.WriteString(":")
+
+        rb := *(values[i].(*sql.RawBytes))
+        WriteValue(buf, mode, colTypes[i], rb, isBinary)
+    }
+    buf.WriteString("}")
+}
+
+// WriteValue writes a single column value according to the mode
+func WriteValue(buf *bytes.Buffer, mode Mode, colType *sql.ColumnType, rb []byte, isBinary func(string) bool) {
+    if rb == nil {
+        buf.WriteString("null")
+        return
+    }
+
+    typeName := strings.ToUpper(colType.DatabaseTypeName())
+    if isBinary != nil && isBinary(typeName) {
+        WriteString(buf, base64.StdEncoding.EncodeToString(rb))
+        return
+    }
+
+    if mode == Strings {
+        WriteString(buf, string(rb))
+        return
+    }
+
+    switch Kind(colType) {
+    case KindInteger, KindDecimal:
+        if num, ok := jsonNumber(rb); ok {
+            buf.WriteString(num)
+            return
+        }
+    case KindBool:
+        if b, ok := ParseBool(rb); ok {
+            buf.WriteString(strconv.FormatBool(b))
+            return
+        }
+    case KindTime:
+        if t, ok := ParseTime(string(rb)); ok {
+            WriteString(buf, t.Format(time.RFC3339Nano))
+            return
+        }
+    case KindJSON:
+        if json.Valid(rb) {
+            buf.Write(rb)
+            return
+        }
+    }
+
+    WriteString(buf, string(rb))
+}
+
+// TextValue returns a non-null column value as plain text for the delimited
+// exports: binary columns base64-encoded as in the JSON, anything else as read
+func TextValue(colType *sql.ColumnType, rb []byte, isBinary func(string) bool) string {
+    if isBinary != nil && isBinary(strings.ToUpper(colType.DatabaseTypeName())) {
+        return base64.StdEncoding.EncodeToString(rb)
+    }
+    return string(rb)
+}
+
+// ValueKind is the JSON family a column is encoded as in Typed mode
+type ValueKind int
+
+const (
+    KindString ValueKind = iota
+    KindInteger
+    KindDecimal
+    KindBool
+    KindTime
+    KindJSON
+)
+
+// Kind classifies a column by its database type name, falling back to the
+// driver scan type for expressions that carry no declared type
+func Kind(colType *sql.ColumnType) ValueKind {
+    typeName := strings.ToUpper(colType.DatabaseTypeName())
+    if i := strings.Index(typeName, "("); i > 0 {
+        typeName = strings.TrimSpace(typeName[:i])
+    }
+
+    switch typeName {
+    case "INT", "INTEGER", "BIGINT", "SMALLINT", "TINYINT", "MEDIUMINT",
+        "INT2", "INT4", "INT8", "SERIAL", "BIGSERIAL", "SMALLSERIAL",
+        "UNSIGNED INT", "UNSIGNED BIGINT", "UNSIGNED SMALLINT", "UNSIGNED TINYINT", "UNSIGNED MEDIUMINT", "YEAR":
+        return KindInteger
+    case "FLOAT", "DOUBLE", "DOUBLE PRECISION", "REAL", "FLOAT4", "FLOAT8",
+        "DECIMAL", "NUMERIC", "NUMBER", "MONEY", "SMALLMONEY", "BINARY_FLOAT", "BINARY_DOUBLE":
+        return KindDecimal
+    case "BOOL", "BOOLEAN", "BIT":
+        return KindBool
+    case "DATE", "DATETIME", "DATETIME2", "SMALLDATETIME", "DATETIMEOFFSET",
+        "TIMESTAMP", "TIMESTAMPTZ", "TIMESTAMP WITH TIME ZONE", "TIMESTAMP WITH LOCAL TIME ZONE", "TIMESTAMP WITHOUT TIME ZONE":
+        return KindTime
+    case "JSON", "JSONB":
+        return KindJSON
+    }
+
+    if typeName != "" {
+        return KindString
+    }
+
+    scanType := colType.ScanType()
+    if scanType == nil {
+        return KindString
+    }
+    switch scanType {
+    case reflect.TypeOf(sql.NullInt64{}), reflect.TypeOf(sql.NullInt32{}), reflect.TypeOf(sql.NullInt16{}):
+        return KindInteger
+    case reflect.TypeOf(sql.NullFloat64{}):
+        return KindDecimal
+    case reflect.TypeOf(sql.NullBool{}):
+        return KindBool
+    case reflect.TypeOf(sql.NullTime{}), reflect.TypeOf(time.Time{}):
+        return KindTime
+    }
+    switch scanType.Kind() {
+    case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+        reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+        return KindInteger
+    case reflect.Float32, reflect.Float64:
+        return KindDecimal
+    case reflect.Bool:
+        return KindBool
+    }
+    return KindString
+}
+
+// WriteString writes s as a correctly escaped JSON string
+func WriteString(buf *bytes.Buffer, s string) {
+    encoded, _ := json.Marshal(s)
+    buf.Write(encoded)
+}
+
+// jsonNumber returns the text as a JSON number without going through float64,
+// so DECIMAL/NUMERIC values keep every digit
+func jsonNumber(rb []byte) (string, bool) {
+    s := strings.TrimPrefix(strings.TrimSpace(string(rb)), "+")
+    if s == "" {
+        return "", false
+    }
+    // Oracle can render fractions without the leading zero (.5, -.5)
+    if strings.HasPrefix(s, ".") {
+        s = "0" + s
+    } else if strings.HasPrefix(s, "-.") {
+        s = "-0" + s[1:]
+    }
+    if s[0] != '-' && (s[0] < '0' || s[0] > '9') {
+        return "", false
+    }
+    if !json.Valid([]byte(s)) {
+        return "", false
+    }
+    return s, true
+}
+
+// ParseBool reads the boolean spellings drivers return as text
+func ParseBool(rb []byte) (bool, bool) {
+    switch strings.ToLower(strings.TrimSpace(string(rb))) {
+    case "1", "t", "true", "y", "yes", "\x01":
+        return true, true
+    case "0", "f", "false", "n", "no", "\x00":
+        return false, true
+    }
+    return false, false
+}
+
+// ParseTime reads a temporal value in any of the layouts drivers return as text
+func ParseTime(s string) (time.Time, bool) {
+    s = strings.TrimSpace(s)
+    for _, layout := range timeLayouts {
+        if t, err := time.Parse(layout, s); err == nil {
+            return t, true
+        }
+    }
+    return time.Time{}, false
+}
@@ -1,15 +1,135 @@
 package STRUCTURES
 
+import "encoding/json"
+
 type ErrorResponse struct {
-	Error string `json:"error"`
+	Error      string `json:"error"`
+	Code       string `json:"code,omitempty"`
+	Category   string `json:"category,omitempty"`
+	SQLState   string `json:"sqlstate,omitempty"`
+	VendorCode int    `json:"vendor_code,omitempty"`
+	Constraint string `json:"constraint,omitempty"`
+	Position   int    `json:"position,omitempty"`
 }
 
 type SuccessResponse struct {
-	Status string `json:"status"`
+	Status       string  `json:"status"`
+	Rows         int64   `json:"rows,omitempty"`
+	RowsAffected *int64  `json:"rows_affected,omitempty"`
+	LastInsertId int64   `json:"last_insert_id,omitempty"`
+	ElapsedMs    float64 `json:"elapsed_ms,omitempty"`
 }
 
 type InternalResult struct {
 	Json     string
 	Is_error int
 	Is_empty int
+	Rows     int64 // rows returned, or affected by a write, as reported to the hooks
+}
+
+type TableInfo struct {
+	Schema string `json:"schema"`
+	Name   string `json:"name"`
+	Type   string `json:"type"`
+}
+
+type ColumnInfo struct {
+	Name       string  `json:"name"`
+	Type       string  `json:"type"`
+	Nullable   bool    `json:"nullable"`
+	Default    *string `json:"default"`
+	PrimaryKey bool    `json:"primary_key"`
+	Position   int     `json:"position"`
+}
+
+type IndexInfo struct {
+	Name    string   `json:"name"`
+	Columns []string `json:"columns"`
+	Unique  bool     `json:"unique"`
+	Primary bool     `json:"primary"`
+}
+
+type ForeignKeyInfo struct {
+	Name              string   `json:"name"`
+	Columns           []string `json:"columns"`
+	ReferencedTable   string   `json:"referenced_table"`
+	ReferencedColumns []string `json:"referenced_columns"`
+	OnUpdate          string   `json:"on_update"`
+	OnDelete          string   `json:"on_delete"`
+}
+
+type ProcedureInfo struct {
+	Schema string `json:"schema"`
+	Name   string `json:"name"`
+	Type   string `json:"type"`
+}
+
+type MigrationInfo struct {
+	Version   int64  `json:"version"`
+	Name      string `json:"name"`
+	Applied   bool   `json:"applied"`
+	AppliedAt string `json:"applied_at,omitempty"`
+}
+
+type StatementCacheStats struct {
+	Capacity int   `json:"capacity"`
+	Size     int   `json:"size"`
+	Hits     int64 `json:"hits"`
+	Misses   int64 `json:"misses"`
+}
+
+type PoolStats struct {
+	MaxOpenConnections int     `json:"max_open_connections"`
+	OpenConnections    int     `json:"open_connections"`
+	InUse              int     `json:"in_use"`
+	Idle               int     `json:"idle"`
+	WaitCount          int64   `json:"wait_count"`
+	WaitDurationMs     float64 `json:"wait_duration_ms"`
+	MaxIdleClosed      int64   `json:"max_idle_closed"`
+	MaxIdleTimeClosed  int64   `json:"max_idle_time_closed"`
+	MaxLifetimeClosed  int64   `json:"max_lifetime_closed"`
+	Queries            int64   `json:"queries"`
+	Errors             int64   `json:"errors"`
+	LatencyP50Ms       float64 `json:"latency_p50_ms"`
+	LatencyP90Ms       float64 `json:"latency_p90_ms"`
+	LatencyP99Ms       float64 `json:"latency_p99_ms"`
+	Healthy            bool    `json:"healthy"`
+	Reconnects         int64   `json:"reconnects"`
+}
+
+type ResultColumn struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Nullable *bool  `json:"nullable"`
+}
+
+type ResultSet struct {
+	Columns []ResultColumn  `json:"columns"`
+	Rows    json.RawMessage `json:"rows"`
+}
+
+type ResultEnvelope struct {
+	Resultsets []ResultSet `json:"resultsets"`
+	Messages   []string    `json:"messages"`
+}
+
+type ProcedureResult struct {
+	Resultsets []ResultSet            `json:"resultsets"`
+	Out        map[string]interface{} `json:"out"`
+}
+
+type ResultCacheStats struct {
+	Enabled  bool  `json:"enabled"`
+	Entries  int   `json:"entries"`
+	Bytes    int64 `json:"bytes"`
+	MaxBytes int64 `json:"max_bytes"`
+	Hits     int64 `json:"hits"`
+	Misses   int64 `json:"misses"`
+}
+
+type StatementResult struct {
+	Statement  string          `json:"statement"`
+	Result     json.RawMessage `json:"result,omitempty"`
+	Error      json.RawMessage `json:"error,omitempty"`
+	RolledBack bool            `json:"rolled_back,omitempty"`
 }
@@ -53,122 +53,886 @@ static SQLResult SQLrun(char* driver, char* conexion, char* query, ...) {
     free(argsArray);
     return resultado;
 }
+
+// Transacciones: el handle es un entero opaco devuelto por SQLbeginTx
+extern SQLResult SQLbeginTx(char* driver, char* conexion, char* isolation, long long* handle);
+extern SQLResult SQLrunnerTx(long long handle, char* query, char** args, int argCount);
+extern SQLResult SQLcommit(long long handle);
+extern SQLResult SQLrollback(long long handle);
+extern SQLResult SQLsavepoint(long long handle, char* name);
+extern SQLResult SQLrollbackTo(long long handle, char* name);
+extern SQLResult SQLreleaseSavepoint(long long handle, char* name);
+
+static SQLResult SQLrunTx(long long handle, char* query, ...) {
+    va_list args;
+    va_start(args, query);
+
+    // Contar argumentos (se espera terminados en NULL)
+    int argCount = 0;
+    while (va_arg(args, char*) != NULL) {
+        argCount++;
+    }
+    va_end(args);
+
+    if (argCount == 0) {
+        return SQLrunnerTx(handle, query, NULL, 0);
+    }
+
+    char** argsArray = (char**)malloc(argCount * sizeof(char*));
+    if (argsArray == NULL) {
+        SQLResult errResult;
+        errResult.json = strdup("{\"error\":\"Memory allocation failed\"}");
+        errResult.is_error = 1;
+        errResult.is_empty = 1;
+        return errResult;
+    }
+
+    va_start(args, query);
+    for (int i = 0; i < argCount; i++) {
+        argsArray[i] = va_arg(args, char*);
+    }
+    va_end(args);
+
+    SQLResult resultado = SQLrunnerTx(handle, query, argsArray, argCount);
+
+    free(argsArray);
+    return resultado;
+}
+
+// Igual que SQLrun pero cancela la consulta si supera timeoutMs milisegundos
+extern SQLResult SQLrunnerTimeout(char* driver, char* conexion, char* query, int timeoutMs, char** args, int argCount);
+
+static SQLResult SQLrunTimeout(char* driver, char* conexion, char* query, int timeoutMs, ...) {
+    va_list args;
+    va_start(args, timeoutMs);
+
+    // Contar argumentos (se espera terminados en NULL)
+    int argCount = 0;
+    while (va_arg(args, char*) != NULL) {
+        argCount++;
+    }
+    va_end(args);
+
+    if (argCount == 0) {
+        return SQLrunnerTimeout(driver, conexion, query, timeoutMs, NULL, 0);
+    }
+
+    char** argsArray = (char**)malloc(argCount * sizeof(char*));
+    if (argsArray == NULL) {
+        SQLResult errResult;
+        errResult.json = strdup("{\"error\":\"Memory allocation failed\"}");
+        errResult.is_error = 1;
+        errResult.is_empty = 1;
+        return errResult;
+    }
+
+    va_start(args, timeoutMs);
+    for (int i = 0; i < argCount; i++) {
+        argsArray[i] = va_arg(args, char*);
+    }
+    va_end(args);
+
+    SQLResult resultado = SQLrunnerTimeout(driver, conexion, query, timeoutMs, argsArray, argCount);
+
+    free(argsArray);
+    return resultado;
+}
+
+// Cursores: el handle es un entero opaco devuelto por SQLopenCursor
+extern SQLResult SQLopenCursorArgs(char* driver, char* conexion, char* query, char** args, int argCount, long long* handle);
+extern SQLResult SQLfetchNext(long long handle, int n);
+extern SQLResult SQLcloseCursor(long long handle);
+
+static SQLResult SQLopenCursor(char* driver, char* conexion, char* query, long long* handle, ...) {
+    va_list args;
+    va_start(args, handle);
+
+    // Contar argumentos (se espera terminados en NULL)
+    int argCount = 0;
+    while (va_arg(args, char*) != NULL) {
+        argCount++;
+    }
+    va_end(args);
+
+    if (argCount == 0) {
+        return SQLopenCursorArgs(driver, conexion, query, NULL, 0, handle);
+    }
+
+    char** argsArray = (char**)malloc(argCount * sizeof(char*));
+    if (argsArray == NULL) {
+        SQLResult errResult;
+        errResult.json = strdup("{\"error\":\"Memory allocation failed\"}");
+        errResult.is_error = 1;
+        errResult.is_empty = 1;
+        return errResult;
+    }
+
+    va_start(args, handle);
+    for (int i = 0; i < argCount; i++) {
+        argsArray[i] = va_arg(args, char*);
+    }
+    va_end(args);
+
+    SQLResult resultado = SQLopenCursorArgs(driver, conexion, query, argsArray, argCount, handle);
+
+    free(argsArray);
+    return resultado;
+}
+
+// Streaming NDJSON: se invoca callback una vez por fila (sin el salto de línea).
+// Si callback devuelve distinto de 0 se detiene la lectura.
+typedef int (*SQLRowCallback)(char* line, void* userData);
+
+extern SQLResult SQLstreamNDJSON(char* driver, char* conexion, char* query, SQLRowCallback callback, void* userData, char** args, int argCount);
+
+static inline int callRowCallback(SQLRowCallback callback, char* line, void* userData) {
+    return callback(line, userData);
+}
+
+// Introspección: table acepta "tabla" o "esquema.tabla"
+extern SQLResult SQLlistTables(char* driver, char* conexion);
+extern SQLResult SQLdescribeTable(char* driver, char* conexion, char* table);
+extern SQLResult SQLlistIndexes(char* driver, char* conexion, char* table);
+extern SQLResult SQLlistForeignKeys(char* driver, char* conexion, char* table);
+extern SQLResult SQLlistProcedures(char* driver, char* conexion);
+
+// Migraciones: dir contiene archivos NNNN_nombre.up.sql / NNNN_nombre.down.sql
+extern SQLResult SQLmigrateUp(char* driver, char* conexion, char* dir);
+extern SQLResult SQLmigrateDown(char* driver, char* conexion, char* dir, int n);
+extern SQLResult SQLmigrationStatus(char* driver, char* conexion, char* dir);
+
+// Caché de statements preparados por conexión; size <= 0 la desactiva
+extern SQLResult SQLsetStatementCacheSize(char* driver, char* conexion, int size);
+extern SQLResult SQLstatementCacheStats(char* driver, char* conexion);
+
+// Estado del pool: estadísticas y health checker (intervalMs <= 0 lo detiene)
+extern SQLResult SQLpoolStats(char* driver, char* conexion);
+extern SQLResult SQLstartHealthCheck(int intervalMs);
+extern SQLResult SQLstopHealthCheck();
+
+// Réplicas de lectura: SQLrunnerLoaded usa la conexión del pool y envía las
+// lecturas a las réplicas salvo que SQLpinPrimary la fije al primario
+extern SQLResult SQLloadReplicas(char* driver, char* primary, char** replicas, int replicaCount);
+extern SQLResult SQLpinPrimary(char* driver, char* conexion, int pinned);
+extern SQLResult SQLrunnerLoaded(char* driver, char* conexion, char* query, char** args, int argCount);
+
+// Carga masiva en una transacción: array JSON de objetos o archivo CSV con cabecera
+extern SQLResult SQLbulkLoad(char* driver, char* conexion, char* table, char* jsonArray);
+extern SQLResult SQLbulkLoadCSV(char* driver, char* conexion, char* table, char* path);
+
+// Valores JSON tipados (números, booleanos, fechas RFC3339 y JSON anidado) en vez
+// de cadenas: SQLrunnerTyped para una conexión de un solo uso y SQLsetTypedJSON
+// para la conexión del pool, que afecta a todos los que la comparten
+extern SQLResult SQLrunnerTyped(char* driver, char* conexion, char* query, char** args, int argCount);
+extern SQLResult SQLsetTypedJSON(char* driver, char* conexion, int typed);
+
+// Respuesta con todos los resultsets y sus columnas: {"resultsets":[...],"messages":[...]}
+extern SQLResult SQLsetResultEnvelope(char* driver, char* conexion, int enabled);
+
+// Procedimientos con parámetros IN/OUT/INOUT descritos en un array JSON:
+// [{"name":"id","mode":"IN","type":"int","value":1},{"name":"total","mode":"OUT","type":"decimal"}]
+extern SQLResult SQLcallProcedure(char* driver, char* conexion, char* name, char* params);
+
+// Caché de resultados de lecturas: ttlMs <= 0 la desactiva y maxBytes <= 0 usa
+// 64 MB (DefaultResultCacheBytes); tables vacío la vacía entera
+extern SQLResult SQLsetResultCache(char* driver, char* conexion, int ttlMs, long long maxBytes);
+extern SQLResult SQLinvalidateResultCache(char* driver, char* conexion, char** tables, int tableCount);
+extern SQLResult SQLresultCacheStats(char* driver, char* conexion);
+
+// Registro de consultas en un archivo, una línea JSON por consulta: path vacío lo
+// desactiva; slowMs > 0 registra solo las consultas que tardan slowMs o más
+extern SQLResult SQLlogToFile(char* path, int slowMs);
+
+// Conectores de solo lectura y listas de sentencias permitidas. kinds acepta
+// read, write, ddl, transaction, call, session y unknown; una lista vacía quita el límite
+extern SQLResult SQLsetReadOnly(char* driver, char* conexion, int readOnly);
+extern SQLResult SQLallowStatementKinds(char* driver, char* conexion, char** kinds, int kindCount);
+extern SQLResult SQLallowTables(char* driver, char* conexion, char** tables, int tableCount);
+
+// Scripts: DELIMITER en MySQL, lotes GO en SQL Server, "/" tras los bloques PL/SQL
+// en Oracle y cuerpos $$ en PostgreSQL. Devuelve un array JSON con el resultado o
+// el error de cada sentencia; inTransaction != 0 las ejecuta en una transacción
+extern SQLResult SQLrunnerScript(char* driver, char* conexion, char* script, int inTransaction);
+extern SQLResult SQLrunnerScriptLoaded(char* driver, char* conexion, char* script, int inTransaction);
+
+// Exportación a archivo: format es csv, tsv o ndjson; delimiter vacío usa la coma.
+// quoteAll entrecomilla todos los valores no nulos y bom antepone la marca UTF-8
+// que Excel necesita. Devuelve {"status":"OK","rows":N}
+extern SQLResult SQLexportFile(char* driver, char* conexion, char* query, char* path, char* format, char* delimiter, int quoteAll, int noHeader, int bom, char** args, int argCount);
+
+// Copia entre conexiones, aunque sean de motores distintos. createTable != 0 crea
+// la tabla destino con los tipos equivalentes; se escriben chunkRows filas por
+// transacción (1000 si es <= 0) y, si progress no es NULL, se invoca tras cada bloque
+typedef void (*SQLProgressCallback)(long long rows, void* userData);
+
+extern SQLResult SQLcopyTable(char* sourceDriver, char* sourceConexion, char* sourceTable, char* targetDriver, char* targetConexion, char* targetTable, int createTable, int chunkRows, SQLProgressCallback progress, void* userData);
+extern SQLResult SQLcopyQuery(char* sourceDriver, char* sourceConexion, char* query, char* targetDriver, char* targetConexion, char* targetTable, int createTable, int chunkRows, SQLProgressCallback progress, void* userData, char** args, int argCount);
+
+static inline void callProgressCallback(SQLProgressCallback callback, long long rows, void* userData) {
+    callback(rows, userData);
+}
 */
 import "C"
 import (
-	"encoding/base64"
+	"context"
+	"errors"
 	"encoding/json"
 	"fmt"
+	"log/slog"
+	"os"
     "unsafe"
-	"strconv"
 	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+    ARGS "github.com/IngenieroRicardo/db/ARGS"
     STRC "github.com/IngenieroRicardo/db/STRUCTURES"
-    LDB "github.com/IngenieroRicardo/db/LDB"
-    MDB "github.com/IngenieroRicardo/db/MDB"
-    PDB "github.com/IngenieroRicardo/db/PDB"
-    SDB "github.com/IngenieroRicardo/db/SDB"
-    ODB "github.com/IngenieroRicardo/db/ODB"
+    DB "github.com/IngenieroRicardo/db/go"
+    ENCODER "github.com/IngenieroRicardo/db/ENCODER"
 )
 
 //export SQLrunner
 func SQLrunner(driver *C.char, conexion *C.char, query *C.char, args **C.char, argCount C.int) C.SQLResult {
-    goDriver := C.GoString(driver)
-	goConexion := C.GoString(conexion)
-	goQuery := C.GoString(query)
-	var result C.SQLResult
-
-	var goArgs []interface{}
-	if argCount > 0 {
-		argSlice := (*[1 << 30]*C.char)(unsafe.Pointer(args))[:argCount:argCount]
-		for _, arg := range argSlice {
-			argStr := C.GoString(arg)
-
-			switch {
-			case strings.HasPrefix(argStr, "int::"):
-				intVal, err := strconv.ParseInt(argStr[5:], 10, 64)
-				if err != nil {
-					result.json = C.CString(createErrorJSON(fmt.Sprintf("Error parseando entero: %s", argStr[5:])))
-					result.is_error = 1
-					result.is_empty = 0
-					return result
-				}
-				goArgs = append(goArgs, intVal)
-
-			case strings.HasPrefix(argStr, "float::"), strings.HasPrefix(argStr, "double::"):
-				prefixLen := 7
-				if strings.HasPrefix(argStr, "double::") {
-					prefixLen = 8
-				}
-				floatVal, err := strconv.ParseFloat(argStr[prefixLen:], 64)
-				if err != nil {
-					result.json = C.CString(createErrorJSON(fmt.Sprintf("Error parseando float: %s", argStr[prefixLen:])))
-					result.is_error = 1
-					result.is_empty = 0
-					return result
-				}
-				goArgs = append(goArgs, floatVal)
-
-			case strings.HasPrefix(argStr, "bool::"):
-				boolVal, err := strconv.ParseBool(argStr[6:])
-				if err != nil {
-					result.json = C.CString(createErrorJSON(fmt.Sprintf("Error parseando booleano: %s", argStr[6:])))
-					result.is_error = 1
-					result.is_empty = 0
-					return result
-				}
-				goArgs = append(goArgs, boolVal)
-
-			case strings.HasPrefix(argStr, "null::"):
-				goArgs = append(goArgs, nil)
-
-			case strings.HasPrefix(argStr, "blob::"):
-				data, err := base64.StdEncoding.DecodeString(argStr[6:])
-				if err != nil {
-					result.json = C.CString(createErrorJSON(fmt.Sprintf("Error decodificando blob: %v", err)))
-					result.is_error = 1
-					result.is_empty = 0
-					return result
-				}
-				goArgs = append(goArgs, data)
-
-			default:
-				goArgs = append(goArgs, argStr)
-			}
-		}
-	}
-    switch goDriver {
-    case "sqlite3":
-        SQLResult := LDB.SqlRunInternal(goDriver, goConexion, goQuery, goArgs...)
-        result.json = C.CString(SQLResult.Json)
-        result.is_error = C.int(SQLResult.Is_error)
-        result.is_empty = C.int(SQLResult.Is_empty)
-        return result
-    case "sqlserver":
-        SQLResult := SDB.SqlRunInternal(goDriver, goConexion, goQuery, goArgs...)
-        result.json = C.CString(SQLResult.Json)
-        result.is_error = C.int(SQLResult.Is_error)
-        result.is_empty = C.int(SQLResult.Is_empty)
-        return result
-    case "postgres":
-        SQLResult := PDB.SqlRunInternal(goDriver, goConexion, goQuery, goArgs...)
-        result.json = C.CString(SQLResult.Json)
-        result.is_error = C.int(SQLResult.Is_error)
-        result.is_empty = C.int(SQLResult.Is_empty)
-        return result
-    case "oracle":
-        SQLResult := ODB.SqlRunInternal("godror", goConexion, goQuery, goArgs...)
-        result.json = C.CString(SQLResult.Json)
-        result.is_error = C.int(SQLResult.Is_error)
-        result.is_empty = C.int(SQLResult.Is_empty)
-        return result
-    default:
-        SQLResult := MDB.SqlRunInternal(goDriver, goConexion, goQuery, goArgs...)
-        result.json = C.CString(SQLResult.Json)
-        result.is_error = C.int(SQLResult.Is_error)
-        result.is_empty = C.int(SQLResult.Is_empty)
-        return result
-    }
-	
+    goArgs, err := ARGS.Parse(goStrings(args, argCount))
+    if err != nil {
+        return toSQLResult(STRC.InternalResult{Json: createErrorJSON(err.Error()), Is_error: 1})
+    }
+
+    return toSQLResult(DB.SqlRunInternal(C.GoString(driver), C.GoString(conexion), ENCODER.Strings, C.GoString(query), goArgs...))
+}
+
+//export SQLrunnerTyped
+func SQLrunnerTyped(driver *C.char, conexion *C.char, query *C.char, args **C.char, argCount C.int) C.SQLResult {
+    goArgs, err := ARGS.Parse(goStrings(args, argCount))
+    if err != nil {
+        return toSQLResult(STRC.InternalResult{Json: createErrorJSON(err.Error()), Is_error: 1})
+    }
+
+    return toSQLResult(DB.SqlRunInternal(C.GoString(driver), C.GoString(conexion), ENCODER.Typed, C.GoString(query), goArgs...))
+}
+
+//export SQLrunnerTimeout
+func SQLrunnerTimeout(driver *C.char, conexion *C.char, query *C.char, timeoutMs C.int, args **C.char, argCount C.int) C.SQLResult {
+    ctx := context.Background()
+    if timeoutMs > 0 {
+        var cancel context.CancelFunc
+        ctx, cancel = context.WithTimeout(ctx, time.Duration(timeoutMs)*time.Millisecond)
+        defer cancel()
+    }
+
+    return toSQLResult(DB.SQLrunContext(ctx, C.GoString(driver), C.GoString(conexion), C.GoString(query), goStrings(args, argCount)...))
+}
+
+// handles maps the opaque integers handed to C callers to open transactions and cursors
+var handles = struct {
+    sync.Mutex
+    next int64
+    byID map[int64]interface{}
+}{
+    byID: make(map[int64]interface{}),
+}
+
+func storeHandle(v interface{}) int64 {
+    handles.Lock()
+    defer handles.Unlock()
+    handles.next++
+    handles.byID[handles.next] = v
+    return handles.next
+}
+
+//export SQLbeginTx
+func SQLbeginTx(driver *C.char, conexion *C.char, isolation *C.char, handle *C.longlong) C.SQLResult {
+    *handle = 0
+
+    connector, result, ok := loadConnector(driver, conexion)
+    if !ok {
+        return result
+    }
+
+    tx, err := DB.BeginTx(connector, C.GoString(isolation))
+    if err != nil {
+        return errorResult(connector, err)
+    }
+
+    *handle = C.longlong(storeHandle(tx))
+    return toSQLResult(STRC.InternalResult{Json: createSuccessJSON(), Is_empty: 1})
+}
+
+//export SQLrunnerTx
+func SQLrunnerTx(handle C.longlong, query *C.char, args **C.char, argCount C.int) C.SQLResult {
+    tx, result, ok := lookupTx(handle, false)
+    if !ok {
+        return result
+    }
+
+    return toSQLResult(DB.SQLrunOnTx(tx, C.GoString(query), goStrings(args, argCount)...))
+}
+
+//export SQLcommit
+func SQLcommit(handle C.longlong) C.SQLResult {
+    tx, result, ok := lookupTx(handle, true)
+    if !ok {
+        return result
+    }
+    return errorOrOK(DB.Commit(tx))
+}
+
+//export SQLrollback
+func SQLrollback(handle C.longlong) C.SQLResult {
+    tx, result, ok := lookupTx(handle, true)
+    if !ok {
+        return result
+    }
+    return errorOrOK(DB.Rollback(tx))
+}
+
+//export SQLsavepoint
+func SQLsavepoint(handle C.longlong, name *C.char) C.SQLResult {
+    tx, result, ok := lookupTx(handle, false)
+    if !ok {
+        return result
+    }
+    return errorOrOK(DB.Savepoint(tx, C.GoString(name)))
+}
+
+//export SQLrollbackTo
+func SQLrollbackTo(handle C.longlong, name *C.char) C.SQLResult {
+    tx, result, ok := lookupTx(handle, false)
+    if !ok {
+        return result
+    }
+    return errorOrOK(DB.RollbackToSavepoint(tx, C.GoString(name)))
+}
+
+//export SQLreleaseSavepoint
+func SQLreleaseSavepoint(handle C.longlong, name *C.char) C.SQLResult {
+    tx, result, ok := lookupTx(handle, false)
+    if !ok {
+        return result
+    }
+    return errorOrOK(DB.ReleaseSavepoint(tx, C.GoString(name)))
+}
+
+// lookupTx resolves a handle; release removes it because the transaction is finishing
+func lookupTx(handle C.longlong, release bool) (*DB.Transaction, C.SQLResult, bool) {
+    handles.Lock()
+    defer handles.Unlock()
+
+    tx, exists := handles.byID[int64(handle)].(*DB.Transaction)
+    if !exists {
+        return nil, toSQLResult(STRC.InternalResult{Json: createErrorJSON(fmt.Sprintf("Transacción inexistente: %d", int64(handle))), Is_error: 1}), false
+    }
+    if release {
+        delete(handles.byID, int64(handle))
+    }
+    return tx, C.SQLResult{}, true
+}
+
+//export SQLopenCursorArgs
+func SQLopenCursorArgs(driver *C.char, conexion *C.char, query *C.char, args **C.char, argCount C.int, handle *C.longlong) C.SQLResult {
+    *handle = 0
+
+    connector, result, ok := loadConnector(driver, conexion)
+    if !ok {
+        return result
+    }
+
+    cursor, err := DB.OpenCursor(connector, C.GoString(query), goStrings(args, argCount)...)
+    if err != nil {
+        return errorResult(connector, err)
+    }
+
+    *handle = C.longlong(storeHandle(cursor))
+    return toSQLResult(STRC.InternalResult{Json: createSuccessJSON(), Is_empty: 1})
+}
+
+//export SQLfetchNext
+func SQLfetchNext(handle C.longlong, n C.int) C.SQLResult {
+    cursor, result, ok := lookupCursor(handle, false)
+    if !ok {
+        return result
+    }
+    return toSQLResult(DB.FetchNext(cursor, int(n)))
+}
+
+//export SQLcloseCursor
+func SQLcloseCursor(handle C.longlong) C.SQLResult {
+    cursor, result, ok := lookupCursor(handle, true)
+    if !ok {
+        return result
+    }
+    return errorOrOK(DB.CloseCursor(cursor))
+}
+
+// lookupCursor resolves a cursor handle; release removes it because the cursor is closing
+func lookupCursor(handle C.longlong, release bool) (*DB.Cursor, C.SQLResult, bool) {
+    handles.Lock()
+    defer handles.Unlock()
+
+    cursor, exists := handles.byID[int64(handle)].(*DB.Cursor)
+    if !exists {
+        return nil, toSQLResult(STRC.InternalResult{Json: createErrorJSON(fmt.Sprintf("Cursor inexistente: %d", int64(handle))), Is_error: 1}), false
+    }
+    if release {
+        delete(handles.byID, int64(handle))
+    }
+    return cursor, C.SQLResult{}, true
+}
+
+// callbackWriter hands every NDJSON line to a C callback
+type callbackWriter struct {
+    callback C.SQLRowCallback
+    userData unsafe.Pointer
+}
+
+func (w callbackWriter) Write(p []byte) (int, error) {
+    line := C.CString(strings.TrimSuffix(string(p), "\n"))
+    defer C.free(unsafe.Pointer(line))
+
+    if C.callRowCallback(w.callback, line, w.userData) != 0 {
+        return 0, errors.New("lectura detenida por el callback")
+    }
+    return len(p), nil
+}
+
+//export SQLstreamNDJSON
+func SQLstreamNDJSON(driver *C.char, conexion *C.char, query *C.char, callback C.SQLRowCallback, userData unsafe.Pointer, args **C.char, argCount C.int) C.SQLResult {
+    connector, result, ok := loadConnector(driver, conexion)
+    if !ok {
+        return result
+    }
+
+    total, err := DB.StreamNDJSON(connector, callbackWriter{callback: callback, userData: userData}, C.GoString(query), goStrings(args, argCount)...)
+    if err != nil {
+        return errorResult(connector, err)
+    }
+
+    jsonData, _ := json.Marshal(STRC.SuccessResponse{Status: "OK", Rows: total})
+    return toSQLResult(STRC.InternalResult{Json: string(jsonData), Is_empty: boolToInt(total == 0)})
+}
+
+//export SQLlistTables
+func SQLlistTables(driver *C.char, conexion *C.char) C.SQLResult {
+    connector, result, ok := loadConnector(driver, conexion)
+    if !ok {
+        return result
+    }
+    return toSQLResult(DB.ListTables(connector))
+}
+
+//export SQLdescribeTable
+func SQLdescribeTable(driver *C.char, conexion *C.char, table *C.char) C.SQLResult {
+    connector, result, ok := loadConnector(driver, conexion)
+    if !ok {
+        return result
+    }
+    return toSQLResult(DB.DescribeTable(connector, C.GoString(table)))
+}
+
+//export SQLlistIndexes
+func SQLlistIndexes(driver *C.char, conexion *C.char, table *C.char) C.SQLResult {
+    connector, result, ok := loadConnector(driver, conexion)
+    if !ok {
+        return result
+    }
+    return toSQLResult(DB.ListIndexes(connector, C.GoString(table)))
+}
+
+//export SQLlistForeignKeys
+func SQLlistForeignKeys(driver *C.char, conexion *C.char, table *C.char) C.SQLResult {
+    connector, result, ok := loadConnector(driver, conexion)
+    if !ok {
+        return result
+    }
+    return toSQLResult(DB.ListForeignKeys(connector, C.GoString(table)))
+}
+
+//export SQLlistProcedures
+func SQLlistProcedures(driver *C.char, conexion *C.char) C.SQLResult {
+    connector, result, ok := loadConnector(driver, conexion)
+    if !ok {
+        return result
+    }
+    return toSQLResult(DB.ListProcedures(connector))
+}
+
+//export SQLmigrateUp
+func SQLmigrateUp(driver *C.char, conexion *C.char, dir *C.char) C.SQLResult {
+    connector, result, ok := loadConnector(driver, conexion)
+    if !ok {
+        return result
+    }
+    return toSQLResult(DB.MigrateUp(connector, C.GoString(dir)))
+}
+
+//export SQLmigrateDown
+func SQLmigrateDown(driver *C.char, conexion *C.char, dir *C.char, n C.int) C.SQLResult {
+    connector, result, ok := loadConnector(driver, conexion)
+    if !ok {
+        return result
+    }
+    return toSQLResult(DB.MigrateDown(connector, C.GoString(dir), int(n)))
+}
+
+//export SQLmigrationStatus
+func SQLmigrationStatus(driver *C.char, conexion *C.char, dir *C.char) C.SQLResult {
+    connector, result, ok := loadConnector(driver, conexion)
+    if !ok {
+        return result
+    }
+    return toSQLResult(DB.MigrationStatus(connector, C.GoString(dir)))
+}
+
+//export SQLsetStatementCacheSize
+func SQLsetStatementCacheSize(driver *C.char, conexion *C.char, size C.int) C.SQLResult {
+    connector, result, ok := loadConnector(driver, conexion)
+    if !ok {
+        return result
+    }
+    DB.SetStatementCacheSize(connector, int(size))
+    return errorOrOK(nil)
+}
+
+//export SQLstatementCacheStats
+func SQLstatementCacheStats(driver *C.char, conexion *C.char) C.SQLResult {
+    connector, result, ok := loadConnector(driver, conexion)
+    if !ok {
+        return result
+    }
+    jsonData, _ := json.Marshal(DB.StatementCacheStats(connector))
+    return toSQLResult(STRC.InternalResult{Json: string(jsonData)})
+}
+
+//export SQLpoolStats
+func SQLpoolStats(driver *C.char, conexion *C.char) C.SQLResult {
+    connector, result, ok := loadConnector(driver, conexion)
+    if !ok {
+        return result
+    }
+    return toSQLResult(DB.PoolStats(connector))
+}
+
+//export SQLstartHealthCheck
+func SQLstartHealthCheck(intervalMs C.int) C.SQLResult {
+    DB.StartHealthCheck(time.Duration(intervalMs) * time.Millisecond)
+    return errorOrOK(nil)
+}
+
+//export SQLstopHealthCheck
+func SQLstopHealthCheck() C.SQLResult {
+    DB.StopHealthCheck()
+    return errorOrOK(nil)
+}
+
+//export SQLloadReplicas
+func SQLloadReplicas(driver *C.char, primary *C.char, replicas **C.char, replicaCount C.int) C.SQLResult {
+    _, err := DB.LoadSQLWithReplicas(C.GoString(driver), C.GoString(primary), goStrings(replicas, replicaCount), 0, 0, 0, 0)
+    if err != nil {
+        return toSQLResult(STRC.InternalResult{Json: createErrorJSON(fmt.Sprintf("Error al conectar a la base de datos: %v", err)), Is_error: 1})
+    }
+    return errorOrOK(nil)
+}
+
+//export SQLpinPrimary
+func SQLpinPrimary(driver *C.char, conexion *C.char, pinned C.int) C.SQLResult {
+    connector, result, ok := loadConnector(driver, conexion)
+    if !ok {
+        return result
+    }
+    DB.PinPrimary(connector, pinned != 0)
+    return errorOrOK(nil)
+}
+
+//export SQLrunnerLoaded
+func SQLrunnerLoaded(driver *C.char, conexion *C.char, query *C.char, args **C.char, argCount C.int) C.SQLResult {
+    connector, result, ok := loadConnector(driver, conexion)
+    if !ok {
+        return result
+    }
+    return toSQLResult(DB.SQLrunonLoad(connector, C.GoString(query), goStrings(args, argCount)...))
+}
+
+//export SQLbulkLoad
+func SQLbulkLoad(driver *C.char, conexion *C.char, table *C.char, jsonArray *C.char) C.SQLResult {
+    connector, result, ok := loadConnector(driver, conexion)
+    if !ok {
+        return result
+    }
+    return toSQLResult(DB.BulkLoad(connector, C.GoString(table), C.GoString(jsonArray)))
+}
+
+//export SQLbulkLoadCSV
+func SQLbulkLoadCSV(driver *C.char, conexion *C.char, table *C.char, path *C.char) C.SQLResult {
+    connector, result, ok := loadConnector(driver, conexion)
+    if !ok {
+        return result
+    }
+    return toSQLResult(DB.BulkLoadCSV(connector, C.GoString(table), C.GoString(path)))
+}
+
+//export SQLsetTypedJSON
+func SQLsetTypedJSON(driver *C.char, conexion *C.char, typed C.int) C.SQLResult {
+    connector, result, ok := loadConnector(driver, conexion)
+    if !ok {
+        return result
+    }
+    DB.SetTypedJSON(connector, typed != 0)
+    return errorOrOK(nil)
+}
+
+//export SQLsetResultEnvelope
+func SQLsetResultEnvelope(driver *C.char, conexion *C.char, enabled C.int) C.SQLResult {
+    connector, result, ok := loadConnector(driver, conexion)
+    if !ok {
+        return result
+    }
+    DB.SetResultEnvelope(connector, enabled != 0)
+    return errorOrOK(nil)
+}
+
+//export SQLcallProcedure
+func SQLcallProcedure(driver *C.char, conexion *C.char, name *C.char, params *C.char) C.SQLResult {
+    connector, result, ok := loadConnector(driver, conexion)
+    if !ok {
+        return result
+    }
+    return toSQLResult(DB.CallProcedure(connector, C.GoString(name), C.GoString(params)))
+}
+
+//export SQLsetResultCache
+func SQLsetResultCache(driver *C.char, conexion *C.char, ttlMs C.int, maxBytes C.longlong) C.SQLResult {
+    connector, result, ok := loadConnector(driver, conexion)
+    if !ok {
+        return result
+    }
+    if ttlMs <= 0 {
+        DB.DisableResultCache(connector)
+    } else {
+        DB.EnableResultCache(connector, time.Duration(ttlMs)*time.Millisecond, int64(maxBytes))
+    }
+    return errorOrOK(nil)
+}
+
+//export SQLinvalidateResultCache
+func SQLinvalidateResultCache(driver *C.char, conexion *C.char, tables **C.char, tableCount C.int) C.SQLResult {
+    connector, result, ok := loadConnector(driver, conexion)
+    if !ok {
+        return result
+    }
+    DB.InvalidateResultCache(connector, goStrings(tables, tableCount)...)
+    return errorOrOK(nil)
+}
+
+//export SQLresultCacheStats
+func SQLresultCacheStats(driver *C.char, conexion *C.char) C.SQLResult {
+    connector, result, ok := loadConnector(driver, conexion)
+    if !ok {
+        return result
+    }
+    jsonData, _ := json.Marshal(DB.ResultCacheStats(connector))
+    return toSQLResult(STRC.InternalResult{Json: string(jsonData)})
+}
+
+// queryLog is the hook installed by SQLlogToFile and the file it writes to
+var queryLog struct {
+    sync.Mutex
+    file *os.File
+    hook DB.Hook
+}
+
+//export SQLlogToFile
+func SQLlogToFile(path *C.char, slowMs C.int) C.SQLResult {
+    queryLog.Lock()
+    defer queryLog.Unlock()
+
+    if queryLog.hook != nil {
+        DB.RemoveGlobalHook(queryLog.hook)
+        queryLog.file.Close()
+        queryLog.hook, queryLog.file = nil, nil
+    }
+
+    goPath := C.GoString(path)
+    if goPath == "" {
+        return errorOrOK(nil)
+    }
+    file, err := os.OpenFile(goPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+    if err != nil {
+        return errorOrOK(fmt.Errorf("error al abrir el archivo de registro: %w", err))
+    }
+
+    logger := slog.New(slog.NewJSONHandler(file, nil))
+    hook := DB.NewSlogHook(logger)
+    if slowMs > 0 {
+        hook = DB.NewSlowQueryHook(logger, time.Duration(slowMs)*time.Millisecond)
+    }
+    DB.AddGlobalHook(hook)
+    queryLog.file, queryLog.hook = file, hook
+    return errorOrOK(nil)
+}
+
+//export SQLsetReadOnly
+func SQLsetReadOnly(driver *C.char, conexion *C.char, readOnly C.int) C.SQLResult {
+    connector, result, ok := loadConnector(driver, conexion)
+    if !ok {
+        return result
+    }
+    DB.SetReadOnly(connector, readOnly != 0)
+    return errorOrOK(nil)
+}
+
+//export SQLallowStatementKinds
+func SQLallowStatementKinds(driver *C.char, conexion *C.char, kinds **C.char, kindCount C.int) C.SQLResult {
+    connector, result, ok := loadConnector(driver, conexion)
+    if !ok {
+        return result
+    }
+    var goKinds []DB.StatementKind
+    for _, kind := range goStrings(kinds, kindCount) {
+        goKinds = append(goKinds, DB.StatementKind(strings.ToLower(strings.TrimSpace(kind))))
+    }
+    return errorOrOK(DB.AllowStatementKinds(connector, goKinds...))
+}
+
+//export SQLallowTables
+func SQLallowTables(driver *C.char, conexion *C.char, tables **C.char, tableCount C.int) C.SQLResult {
+    connector, result, ok := loadConnector(driver, conexion)
+    if !ok {
+        return result
+    }
+    DB.AllowTables(connector, goStrings(tables, tableCount)...)
+    return errorOrOK(nil)
+}
+
+//export SQLrunnerScript
+func SQLrunnerScript(driver *C.char, conexion *C.char, script *C.char, inTransaction C.int) C.SQLResult {
+    return toSQLResult(DB.SQLrunScript(C.GoString(driver), C.GoString(conexion), C.GoString(script), inTransaction != 0))
+}
+
+//export SQLrunnerScriptLoaded
+func SQLrunnerScriptLoaded(driver *C.char, conexion *C.char, script *C.char, inTransaction C.int) C.SQLResult {
+    connector, result, ok := loadConnector(driver, conexion)
+    if !ok {
+        return result
+    }
+    return toSQLResult(DB.SQLrunScriptOnLoad(connector, C.GoString(script), inTransaction != 0))
+}
+
+//export SQLexportFile
+func SQLexportFile(driver *C.char, conexion *C.char, query *C.char, path *C.char, format *C.char, delimiter *C.char, quoteAll C.int, noHeader C.int, bom C.int, args **C.char, argCount C.int) C.SQLResult {
+    connector, result, ok := loadConnector(driver, conexion)
+    if !ok {
+        return result
+    }
+
+    opts := DB.ExportOptions{
+        Format:   DB.ExportFormat(strings.ToLower(strings.TrimSpace(C.GoString(format)))),
+        QuoteAll: quoteAll != 0,
+        NoHeader: noHeader != 0,
+        BOM:      bom != 0,
+    }
+    if d := C.GoString(delimiter); d != "" {
+        opts.Delimiter, _ = utf8.DecodeRuneInString(d)
+    }
+
+    total, err := DB.ExportFile(connector, C.GoString(path), opts, C.GoString(query), goStrings(args, argCount)...)
+    if err != nil {
+        return errorResult(connector, err)
+    }
+
+    jsonData, _ := json.Marshal(STRC.SuccessResponse{Status: "OK", Rows: total})
+    return toSQLResult(STRC.InternalResult{Json: string(jsonData), Is_empty: boolToInt(total == 0)})
+}
+
+//export SQLcopyTable
+func SQLcopyTable(sourceDriver *C.char, sourceConexion *C.char, sourceTable *C.char, targetDriver *C.char, targetConexion *C.char, targetTable *C.char, createTable C.int, chunkRows C.int, progress C.SQLProgressCallback, userData unsafe.Pointer) C.SQLResult {
+    source, result, ok := loadConnector(sourceDriver, sourceConexion)
+    if !ok {
+        return result
+    }
+    target, result, ok := loadConnector(targetDriver, targetConexion)
+    if !ok {
+        return result
+    }
+    return toSQLResult(DB.CopyTable(source, C.GoString(sourceTable), target, C.GoString(targetTable), copyOptions(createTable, chunkRows, progress, userData)))
+}
+
+//export SQLcopyQuery
+func SQLcopyQuery(sourceDriver *C.char, sourceConexion *C.char, query *C.char, targetDriver *C.char, targetConexion *C.char, targetTable *C.char, createTable C.int, chunkRows C.int, progress C.SQLProgressCallback, userData unsafe.Pointer, args **C.char, argCount C.int) C.SQLResult {
+    source, result, ok := loadConnector(sourceDriver, sourceConexion)
+    if !ok {
+        return result
+    }
+    target, result, ok := loadConnector(targetDriver, targetConexion)
+    if !ok {
+        return result
+    }
+    return toSQLResult(DB.CopyQuery(source, C.GoString(query), target, C.GoString(targetTable), copyOptions(createTable, chunkRows, progress, userData), goStrings(args, argCount)...))
+}
+
+// copyOptions hands the progress of a copy to the C callback, when there is one
+func copyOptions(createTable C.int, chunkRows C.int, progress C.SQLProgressCallback, userData unsafe.Pointer) DB.CopyOptions {
+    opts := DB.CopyOptions{CreateTable: createTable != 0, ChunkRows: int(chunkRows)}
+    if progress != nil {
+        opts.Progress = func(rows int64) {
+            C.callProgressCallback(progress, C.longlong(rows), userData)
+        }
+    }
+    return opts
+}
+
+// loadConnector returns the pooled connector for driver and conexion
+func loadConnector(driver *C.char, conexion *C.char) (*DB.Connector, C.SQLResult, bool) {
+    connector, err := DB.LoadSQL(C.GoString(driver), C.GoString(conexion), 0, 0, 0, 0)
+    if err != nil {
+        return nil, toSQLResult(STRC.InternalResult{Json: createErrorJSON(fmt.Sprintf("Error al conectar a la base de datos: %v", err)), Is_error: 1}), false
+    }
+    return connector, C.SQLResult{}, true
+}
+
+// goStrings copies a C array of argCount strings
+func goStrings(args **C.char, argCount C.int) []string {
+    var goArgs []string
+    if argCount > 0 {
+        argSlice := (*[1 << 30]*C.char)(unsafe.Pointer(args))[:argCount:argCount]
+        for _, arg := range argSlice {
+            goArgs = append(goArgs, C.GoString(arg))
+        }
+    }
+    return goArgs
+}
+
+func boolToInt(b bool) int {
+    if b {
+        return 1
+    }
+    return 0
+}
+
+func errorOrOK(err error) C.SQLResult {
+    if err != nil {
+        return errorResult(nil, err)
+    }
+    return toSQLResult(STRC.InternalResult{Json: createSuccessJSON(), Is_empty: 1})
+}
+
+// errorResult reports err with the SQLSTATE, vendor code and category of the driver error
+func errorResult(connector *DB.Connector, err error) C.SQLResult {
+    jsonData, _ := json.Marshal(DB.DescribeError(connector, err))
+    return toSQLResult(STRC.InternalResult{Json: string(jsonData), Is_error: 1})
+}
+
+func toSQLResult(r STRC.InternalResult) C.SQLResult {
+    var result C.SQLResult
+    result.json = C.CString(r.Json)
+    result.is_error = C.int(r.Is_error)
+    result.is_empty = C.int(r.Is_empty)
+    return result
+}
+
+func createSuccessJSON() string {
+    successResp := STRC.SuccessResponse{Status: "OK"}
+    jsonData, _ := json.Marshal(successResp)
+    return string(jsonData)
 }
 
 func createErrorJSON(message string) string {
@@ -0,0 +1,404 @@
+package db
+
+import (
+    "context"
+    "database/sql"
+    "encoding/json"
+    "fmt"
+    "regexp"
+    "sort"
+    "strconv"
+    "strings"
+    "time"
+
+    FILE "github.com/WebPrivada/SDK/file/go"
+    mssql "github.com/denisenkom/go-mssqldb"
+    "github.com/godror/godror"
+    "github.com/lib/pq"
+
+    STRC "github.com/IngenieroRicardo/db/STRUCTURES"
+)
+
+// BulkDialect is implemented by dialects with a native bulk-load path. rows
+// holds one value per column; the load runs inside tx. Dialects without it
+// use multi-row INSERT ... VALUES statements.
+type BulkDialect interface {
+    BulkLoad(ctx context.Context, tx *sql.Tx, table string, columns []string, rows [][]any) error
+}
+
+var (
+    // csvNumber matches the CSV fields loaded as numbers; anything else, such
+    // as "007", stays text
+    csvNumber = regexp.MustCompile(`^-?(?:0|[1-9]\d*)(?:\.\d+)?$`)
+    // bulkColumn guards the column names, which come from the data and end up in the SQL
+    bulkColumn = regexp.MustCompile("^" + bulkIdentifier + "$")
+    // bulkTable guards the table names the same way, qualified by up to two names
+    bulkTable = regexp.MustCompile("^" + bulkIdentifier + "(?:\\." + bulkIdentifier + "){0,2}$")
+)
+
+// bulkIdentifier is a plain or quoted identifier
+const bulkIdentifier = "(?:[A-Za-z_][A-Za-z0-9_$#]*|\"[^\"]+\"|`[^`]+`|\\[[^\\]]+\\])"
+
+// BulkLoad inserts the objects of a JSON array into table in one transaction.
+// The columns are the keys found in the objects; a missing key loads NULL.
+func BulkLoad(connector *Connector, table string, jsonArray string) STRC.InternalResult {
+    decoder := json.NewDecoder(strings.NewReader(jsonArray))
+    decoder.UseNumber()
+
+    var objects []map[string]interface{}
+    if err := decoder.Decode(&objects); err != nil {
+        return bulkError(fmt.Sprintf("error al parsear JSON: %v", err))
+    }
+    if len(objects) == 0 {
+        return bulkError("el array JSON está vacío")
+    }
+
+    seen := make(map[string]bool)
+    var columns []string
+    for _, object := range objects {
+        for key := range object {
+            if !seen[key] {
+                seen[key] = true
+                columns = append(columns, key)
+            }
+        }
+    }
+    sort.Strings(columns)
+
+    rows := make([][]any, len(objects))
+    for i, object := range objects {
+        row := make([]any, len(columns))
+        for j, column := range columns {
+            row[j] = jsonArg(object[column])
+        }
+        rows[i] = row
+    }
+
+    return bulkLoad(connector, table, columns, rows)
+}
+
+// BulkLoadCSV loads a CSV file into table in one transaction. The first record
+// names the columns, empty fields load NULL and plain numbers load as numbers.
+func BulkLoadCSV(connector *Connector, table string, path string) STRC.InternalResult {
+    records, err := FILE.RCSVFile(path)
+    if err != nil {
+        return bulkError(fmt.Sprintf("error al leer CSV: %v", err))
+    }
+    if len(records) < 2 {
+        return bulkError("el CSV no tiene filas de datos")
+    }
+
+    columns := records[0]
+    rows := make([][]any, len(records)-1)
+    for i, record := range records[1:] {
+        row := make([]any, len(columns))
+        for j, field := range record {
+            row[j] = csvValue(field)
+        }
+        rows[i] = row
+    }
+
+    return bulkLoad(connector, table, columns, rows)
+}
+
+func csvValue(field string) any {
+    if field == "" {
+        return nil
+    }
+    if csvNumber.MatchString(field) {
+        if n, err := strconv.ParseInt(field, 10, 64); err == nil {
+            return n
+        }
+        if f, err := strconv.ParseFloat(field, 64); err == nil {
+            return f
+        }
+    }
+    return field
+}
+
+// bulkLoad runs the load on the primary and reports the rows loaded
+func bulkLoad(connector *Connector, table string, columns []string, rows [][]any) STRC.InternalResult {
+    if !bulkTable.MatchString(table) {
+        return bulkError(fmt.Sprintf("nombre de tabla no válido: %q", table))
+    }
+    for _, column := range columns {
+        if !bulkColumn.MatchString(column) {
+            return bulkError(fmt.Sprintf("nombre de columna no válido: %q", column))
+        }
+    }
+    insert := insertValuesSQL(connector.dialect, table, columns, 1)
+    if err := connector.allows(insert); err != nil {
+        return errorResult(context.Background(), connector.dialect, "", err)
+    }
+
+    // Los hooks ven la carga como un solo INSERT, sin los valores
+    return observe(context.Background(), hooksFor(connector), connector.driver, insert, nil, func() STRC.InternalResult {
+        return runBulkLoad(connector, table, columns, rows)
+    })
+}
+
+// runBulkLoad loads the rows once their names have been checked
+func runBulkLoad(connector *Connector, table string, columns []string, rows [][]any) STRC.InternalResult {
+    db, err := connector.handle()
+    if err != nil {
+        return bulkError(err.Error())
+    }
+
+    ctx, cancel := statementContext(context.Background(), connector)
+    defer cancel()
+
+    start := time.Now()
+    if err := loadRows(ctx, db, connector.dialect, table, columns, rows); err != nil {
+        return errorResult(ctx, connector.dialect, "", err)
+    }
+    connector.cachedResults().invalidate(table)
+
+    jsonData, _ := json.Marshal(STRC.SuccessResponse{
+        Status:    "OK",
+        Rows:      int64(len(rows)),
+        ElapsedMs: elapsedMs(time.Since(start)),
+    })
+    return STRC.InternalResult{
+        Json:     string(jsonData),
+        Is_error: 0,
+        Is_empty: 0,
+        Rows:     int64(len(rows)),
+    }
+}
+
+// loadRows inserts rows into table in one transaction, through the native
+// bulk path of d when it has one
+func loadRows(ctx context.Context, db *sql.DB, d Dialect, table string, columns []string, rows [][]any) error {
+    tx, err := db.BeginTx(ctx, nil)
+    if err != nil {
+        return fmt.Errorf("error al iniciar transacción: %w", err)
+    }
+
+    if bd, ok := d.(BulkDialect); ok {
+        err = bd.BulkLoad(ctx, tx, table, columns, rows)
+    } else {
+        err = bulkInsertValues(ctx, tx, d, table, columns, rows, 999)
+    }
+    if err != nil {
+        tx.Rollback()
+        return err
+    }
+    if err := tx.Commit(); err != nil {
+        return fmt.Errorf("error al confirmar transacción: %w", err)
+    }
+    return nil
+}
+
+func bulkError(message string) STRC.InternalResult {
+    return STRC.InternalResult{
+        Json:     createErrorJSON(message),
+        Is_error: 1,
+        Is_empty: 0,
+    }
+}
+
+// bulkInsertValues sends the rows as INSERT ... VALUES (...),(...) statements
+// holding as many rows as fit in maxParams placeholders
+func bulkInsertValues(ctx context.Context, tx *sql.Tx, d Dialect, table string, columns []string, rows [][]any, maxParams int) error {
+    perStmt := maxParams / len(columns)
+    if perStmt < 1 {
+        perStmt = 1
+    }
+    if perStmt > 1000 {
+        perStmt = 1000
+    }
+
+    var stmt *sql.Stmt
+    stmtRows := 0
+    defer func() {
+        if stmt != nil {
+            stmt.Close()
+        }
+    }()
+
+    for start := 0; start < len(rows); start += perStmt {
+        chunk := rows[start:min(start+perStmt, len(rows))]
+        if len(chunk) != stmtRows {
+            // Solo el último bloque puede ser más corto: se prepara otra vez
+            if stmt != nil {
+                stmt.Close()
+            }
+            var err error
+            stmt, err = tx.PrepareContext(ctx, insertValuesSQL(d, table, columns, len(chunk)))
+            if err != nil {
+                return fmt.Errorf("Error en la consulta SQL: %w", err)
+            }
+            stmtRows = len(chunk)
+        }
+
+        args := make([]any, 0, len(chunk)*len(columns))
+        for _, row := range chunk {
+            args = append(args, row...)
+        }
+        if _, err := stmt.ExecContext(ctx, args...); err != nil {
+            return fmt.Errorf("Error en la consulta SQL: %w", err)
+        }
+    }
+    return nil
+}
+
+func insertValuesSQL(d Dialect, table string, columns []string, rowCount int) string {
+    var sb strings.Builder
+    sb.WriteString("INSERT INTO ")
+    sb.WriteString(table)
+    sb.WriteString(" (")
+    sb.WriteString(strings.Join(columns, ", "))
+    sb.WriteString(") VALUES ")
+
+    n := 1
+    for r := 0; r < rowCount; r++ {
+        if r > 0 {
+            sb.WriteString(", ")
+        }
+        sb.WriteString("(")
+        for c := range columns {
+            if c > 0 {
+                sb.WriteString(", ")
+            }
+            sb.WriteString(d.Placeholder(n))
+            n++
+        }
+        sb.WriteString(")")
+    }
+    return sb.String()
+}
+
+// copyRows feeds the rows to a statement prepared for a COPY or bulk-copy
+// protocol and flushes it with a final Exec without arguments
+func copyRows(ctx context.Context, tx *sql.Tx, query string, rows [][]any) error {
+    stmt, err := tx.PrepareContext(ctx, query)
+    if err != nil {
+        return fmt.Errorf("Error en la consulta SQL: %w", err)
+    }
+    defer stmt.Close()
+
+    for _, row := range rows {
+        if _, err := stmt.ExecContext(ctx, row...); err != nil {
+            return fmt.Errorf("Error en la consulta SQL: %w", err)
+        }
+    }
+    if _, err := stmt.ExecContext(ctx); err != nil {
+        return fmt.Errorf("Error en la consulta SQL: %w", err)
+    }
+    return nil
+}
+
+// SQLite has a limit of 999 host parameters per statement before 3.32
+func (s sqliteDialect) BulkLoad(ctx context.Context, tx *sql.Tx, table string, columns []string, rows [][]any) error {
+    return bulkInsertValues(ctx, tx, s, table, columns, rows, 999)
+}
+
+// MySQL accepts up to 65535 placeholders per prepared statement
+func (m mysqlDialect) BulkLoad(ctx context.Context, tx *sql.Tx, table string, columns []string, rows [][]any) error {
+    return bulkInsertValues(ctx, tx, m, table, columns, rows, 65535)
+}
+
+func (p postgresDialect) BulkLoad(ctx context.Context, tx *sql.Tx, table string, columns []string, rows [][]any) error {
+    // pq quotes the identifiers, so they are folded here the way the server would
+    folded := make([]string, len(columns))
+    for i, column := range columns {
+        _, folded[i] = splitTableName(p, column)
+    }
+
+    schema, name := splitTableName(p, table)
+    query := pq.CopyIn(name, folded...)
+    if schema != nil {
+        query = pq.CopyInSchema(schema.(string), name, folded...)
+    }
+    return copyRows(ctx, tx, query, rows)
+}
+
+func (s sqlserverDialect) BulkLoad(ctx context.Context, tx *sql.Tx, table string, columns []string, rows [][]any) error {
+    // La copia masiva busca las columnas por nombre, sin corchetes
+    names := make([]string, len(columns))
+    for i, column := range columns {
+        _, names[i] = splitTableName(s, column)
+    }
+    return copyRows(ctx, tx, mssql.CopyIn(table, mssql.BulkOptions{}, names...), rows)
+}
+
+// oracleBulkRows is how many rows are bound per array Exec
+const oracleBulkRows = 5000
+
+// Oracle binds one slice per column and runs the INSERT once per block of rows
+func (o oracleDialect) BulkLoad(ctx context.Context, tx *sql.Tx, table string, columns []string, rows [][]any) error {
+    query := insertValuesSQL(o, table, columns, 1)
+    for start := 0; start < len(rows); start += oracleBulkRows {
+        chunk := rows[start:min(start+oracleBulkRows, len(rows))]
+        args := make([]any, len(columns))
+        for c := range columns {
+            args[c] = oracleColumn(chunk, c)
+        }
+        if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+            return fmt.Errorf("Error en la consulta SQL: %w", err)
+        }
+    }
+    return nil
+}
+
+// oracleColumn collects column c of rows as []godror.Number when every value
+// is numeric, as []time.Time or [][]byte when every value is a time or binary,
+// and as []string otherwise; the empty string, the zero time and nil bind NULL
+func oracleColumn(rows [][]any, c int) any {
+    numeric, times, binary := true, true, true
+    for _, row := range rows {
+        switch row[c].(type) {
+        case nil:
+        case int64, float64:
+            times, binary = false, false
+        case time.Time:
+            numeric, binary = false, false
+        case []byte:
+            numeric, times = false, false
+        default:
+            numeric, times, binary = false, false, false
+        }
+    }
+
+    switch {
+    case numeric:
+        values := make([]godror.Number, len(rows))
+        for i, row := range rows {
+            switch v := row[c].(type) {
+            case int64:
+                values[i] = godror.Number(strconv.FormatInt(v, 10))
+            case float64:
+                values[i] = godror.Number(strconv.FormatFloat(v, 'f', -1, 64))
+            }
+        }
+        return values
+    case times:
+        values := make([]time.Time, len(rows))
+        for i, row := range rows {
+            values[i], _ = row[c].(time.Time)
+        }
+        return values
+    case binary:
+        values := make([][]byte, len(rows))
+        for i, row := range rows {
+            values[i], _ = row[c].([]byte)
+        }
+        return values
+    }
+
+    values := make([]string, len(rows))
+    for i, row := range rows {
+        switch v := row[c].(type) {
+        case nil:
+        case bool:
+            values[i] = strconv.Itoa(boolToInt(v))
+        case []byte:
+            values[i] = string(v)
+        case time.Time:
+            values[i] = v.Format("2006-01-02 15:04:05.999999999")
+        default:
+            values[i] = fmt.Sprint(v)
+        }
+    }
+    return values
+}
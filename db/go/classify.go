@@ -0,0 +1,773 @@
+package db
+
+import (
+    "strings"
+)
+
+// StatementKind is the class of a SQL statement, as reported by ClassifyStatement
+type StatementKind string
+
+const (
+    StatementRead        StatementKind = "read"        // SELECT, WITH ... SELECT, SHOW, EXPLAIN, VALUES
+    StatementWrite       StatementKind = "write"       // INSERT, UPDATE, DELETE, MERGE, SELECT ... INTO, COPY ... FROM/TO a file
+    StatementDDL         StatementKind = "ddl"         // CREATE, ALTER, DROP, TRUNCATE, GRANT, REVOKE
+    StatementTransaction StatementKind = "transaction" // BEGIN, COMMIT, ROLLBACK, SAVEPOINT, SET TRANSACTION
+    StatementCall        StatementKind = "call"        // CALL, EXEC, DO and anonymous BEGIN ... END blocks
+    StatementSession     StatementKind = "session"     // SET, USE, PRAGMA with a value
+    StatementUnknown     StatementKind = "unknown"
+)
+
+// Statement is one statement of a query as seen by the classifier
+type Statement struct {
+    Kind StatementKind
+    // Verb is the first keyword in upper case, or the statement a WITH clause introduces
+    Verb string
+    // Locking is set on reads that lock rows: FOR UPDATE, FOR SHARE, LOCK IN SHARE MODE
+    Locking bool
+    // Tables lists the tables the statement names, in lower case and qualified
+    // as written; CTE names and table functions are left out
+    Tables []string
+}
+
+// kindRank orders the kinds by how much a statement may change, for ClassifyStatement
+var kindRank = map[StatementKind]int{
+    StatementRead:        0,
+    StatementTransaction: 1,
+    StatementSession:     2,
+    StatementCall:        3,
+    StatementWrite:       4,
+    StatementDDL:         5,
+    StatementUnknown:     6,
+}
+
+// ClassifyStatements splits query into statements, skipping comments, string
+// literals, quoted identifiers and dollar-quoted bodies the way the engine of
+// d reads them, and classifies each. A procedural block (BEGIN ... END,
+// DECLARE, CREATE PROCEDURE ... BEGIN) is one statement up to the end of query.
+func ClassifyStatements(d Dialect, query string) []Statement {
+    var statements []Statement
+    for _, tokens := range lexStatements(d, query).statements {
+        statements = append(statements, classifyStatement(tokens))
+    }
+    return statements
+}
+
+// ClassifyStatement returns the kind of query. For a batch it is the kind of
+// the statement that may change the most; an empty query is unknown.
+func ClassifyStatement(d Dialect, query string) StatementKind {
+    kind := StatementUnknown
+    for i, st := range ClassifyStatements(d, query) {
+        if i == 0 || kindRank[st.Kind] > kindRank[kind] {
+            kind = st.Kind
+        }
+    }
+    return kind
+}
+
+// statementVerb returns the verb of the first statement of query
+func statementVerb(d Dialect, query string) string {
+    statements := ClassifyStatements(d, query)
+    if len(statements) == 0 {
+        return ""
+    }
+    return statements[0].Verb
+}
+
+// sqlSyntax holds the lexical rules that differ between engines
+type sqlSyntax struct {
+    doubleQuoteStrings bool // MySQL: "..." is a literal, not an identifier
+    backslashEscapes   bool // MySQL: a backslash escapes the next character of a literal
+    modalBackslash     bool // a session setting decides whether backslashes escape in literals
+    hashComments       bool // MySQL: # opens a comment up to the end of the line
+    spacedDashes       bool // MySQL: -- opens a comment only before a blank
+    versionComments    bool // MySQL: the text of /*! ... */ is run
+    escapeStrings      bool // PostgreSQL: E'...' literals take backslash escapes
+    dollarQuotes       bool // PostgreSQL: $tag$ ... $tag$ bodies
+    nestedComments     bool // PostgreSQL and SQL Server: /* */ comments nest
+    brackets           bool // SQL Server and SQLite: [identifier]
+    qQuotes            bool // Oracle: q'[...]' literals
+}
+
+// genericSyntax accepts the quotes and comments of every engine, as the
+// lexer did before it knew the dialect
+var genericSyntax = sqlSyntax{modalBackslash: true, dollarQuotes: true, brackets: true}
+
+// syntaxFor returns the lexical rules of the engine behind d. A bare
+// GenericDialect takes the rules of the dialect registered for its driver.
+func syntaxFor(d Dialect) sqlSyntax {
+    switch d := d.(type) {
+    case mysqlDialect:
+        return sqlSyntax{doubleQuoteStrings: true, backslashEscapes: true, modalBackslash: true,
+            hashComments: true, spacedDashes: true, versionComments: true}
+    case postgresDialect:
+        return sqlSyntax{modalBackslash: true, escapeStrings: true, dollarQuotes: true, nestedComments: true}
+    case sqlserverDialect:
+        return sqlSyntax{nestedComments: true, brackets: true}
+    case sqliteDialect:
+        return sqlSyntax{brackets: true}
+    case oracleDialect:
+        return sqlSyntax{qQuotes: true}
+    case GenericDialect:
+        registered := dialectFor(d.Driver)
+        if _, generic := registered.(GenericDialect); !generic {
+            return syntaxFor(registered)
+        }
+    }
+    return genericSyntax
+}
+
+// wordByte reports whether c continues a word
+func (x sqlSyntax) wordByte(c byte) bool {
+    return isWordByte(c) && !(c == '#' && x.hashComments)
+}
+
+type spanKind int
+
+const (
+    noSpan spanKind = iota
+    literalSpan
+    identSpan
+    commentSpan
+    dollarSpan
+)
+
+// sqlSpan is a literal, quoted identifier, comment or dollar-quoted body of a query
+type sqlSpan struct {
+    kind spanKind
+    end  int // index after the span; a line comment ends before its newline
+    // unterminated is set when the query ends before the span is closed
+    unterminated bool
+    // ambiguous is set when a backslash before a quote decides where a
+    // literal ends, which depends on the sql_mode of MySQL and on
+    // standard_conforming_strings in PostgreSQL
+    ambiguous bool
+}
+
+// span returns the literal, quoted identifier, comment or dollar-quoted body
+// that starts at query[i], or a span of kind noSpan. The opening of a MySQL
+// /*! ... */ comment is returned as a comment on its own, since the text
+// inside it is run.
+func (x sqlSyntax) span(query string, i int) sqlSpan {
+    n := len(query)
+    c := query[i]
+    var next byte
+    if i+1 < n {
+        next = query[i+1]
+    }
+    wordStart := i == 0 || !isIdentChar(query[i-1])
+
+    switch {
+    case c == '\'':
+        return x.quoted(query, i, '\'', literalSpan, x.backslashEscapes, x.modalBackslash)
+    case c == '"' && x.doubleQuoteStrings:
+        return x.quoted(query, i, '"', literalSpan, x.backslashEscapes, x.modalBackslash)
+    case c == '"' || c == '`':
+        return x.quoted(query, i, c, identSpan, false, false)
+    case c == '[' && x.brackets:
+        return x.quoted(query, i, ']', identSpan, false, false)
+    case (c == 'E' || c == 'e') && next == '\'' && x.escapeStrings && wordStart:
+        return x.quoted(query, i+1, '\'', literalSpan, true, false)
+    case (c == 'Q' || c == 'q') && next == '\'' && x.qQuotes && wordStart:
+        return qQuoted(query, i+1)
+    case (c == 'N' || c == 'n') && (next == 'Q' || next == 'q') && i+2 < n && query[i+2] == '\'' && x.qQuotes && wordStart:
+        return qQuoted(query, i+2)
+    case c == '-' && next == '-' && (!x.spacedDashes || i+2 == n || query[i+2] <= ' '),
+        c == '#' && x.hashComments:
+        j := strings.IndexByte(query[i:], '\n')
+        if j < 0 {
+            return sqlSpan{kind: commentSpan, end: n}
+        }
+        return sqlSpan{kind: commentSpan, end: i + j}
+    case c == '/' && next == '*' && x.versionComments && i+2 < n && query[i+2] == '!':
+        j := i + 3
+        for j < n && isDigit(query[j]) {
+            j++
+        }
+        return sqlSpan{kind: commentSpan, end: j}
+    case c == '/' && next == '*':
+        depth := 1
+        j := i + 2
+        for j < n && depth > 0 {
+            switch {
+            case query[j] == '*' && j+1 < n && query[j+1] == '/':
+                depth--
+                j += 2
+            case query[j] == '/' && j+1 < n && query[j+1] == '*' && x.nestedComments:
+                depth++
+                j += 2
+            default:
+                j++
+            }
+        }
+        return sqlSpan{kind: commentSpan, end: j, unterminated: depth > 0}
+    case c == '$' && x.dollarQuotes && next != 0 && !isDigit(next) && wordStart:
+        j := i + 1
+        for j < n && isIdentChar(query[j]) {
+            j++
+        }
+        if j >= n || query[j] != '$' {
+            return sqlSpan{}
+        }
+        tag := query[i : j+1]
+        k := strings.Index(query[j+1:], tag)
+        if k < 0 {
+            return sqlSpan{kind: dollarSpan, end: n, unterminated: true}
+        }
+        return sqlSpan{kind: dollarSpan, end: j + 1 + k + len(tag)}
+    }
+    return sqlSpan{}
+}
+
+// quoted reads the literal or identifier opened at query[open] up to the
+// close character. A doubled close character is an escaped one and, with
+// backslash, so is one after a backslash. With modal, a backslash that
+// decides where the literal ends makes the span ambiguous.
+func (x sqlSyntax) quoted(query string, open int, close byte, kind spanKind, backslash, modal bool) sqlSpan {
+    sp := sqlSpan{kind: kind}
+    n := len(query)
+    for j := open + 1; j < n; j++ {
+        switch {
+        case query[j] == '\\' && backslash:
+            if j+1 < n && query[j+1] == close && modal {
+                sp.ambiguous = true
+            }
+            j++
+        case query[j] == close:
+            if modal && !backslash && oddBackslashes(query, open+1, j) {
+                sp.ambiguous = true
+            }
+            if j+1 < n && query[j+1] == close {
+                j++
+                continue
+            }
+            sp.end = j + 1
+            return sp
+        }
+    }
+    sp.end, sp.unterminated = n, true
+    return sp
+}
+
+// oddBackslashes reports whether an odd run of backslashes ends at query[j-1]
+func oddBackslashes(query string, from, j int) bool {
+    count := 0
+    for k := j - 1; k >= from && query[k] == '\\'; k-- {
+        count++
+    }
+    return count%2 == 1
+}
+
+// qQuoted reads an Oracle q'<delimiter>...<delimiter>' literal whose quote is at query[open]
+func qQuoted(query string, open int) sqlSpan {
+    if open+1 >= len(query) {
+        return sqlSpan{kind: literalSpan, end: len(query), unterminated: true}
+    }
+    delimiter := query[open+1]
+    switch delimiter {
+    case '[':
+        delimiter = ']'
+    case '{':
+        delimiter = '}'
+    case '<':
+        delimiter = '>'
+    case '(':
+        delimiter = ')'
+    }
+    k := strings.Index(query[open+2:], string(delimiter)+"'")
+    if k < 0 {
+        return sqlSpan{kind: literalSpan, end: len(query), unterminated: true}
+    }
+    return sqlSpan{kind: literalSpan, end: open + 2 + k + 2}
+}
+
+// sqlToken is a word, in upper case, or a single punctuation character.
+// Literals and quoted identifiers are kept as placeholders so the words
+// around them stay apart.
+type sqlToken struct {
+    text  string
+    name  string // a word as written, or the content of a quoted identifier
+    word  bool
+    depth int // parenthesis depth the token is at
+    pos   int // byte offsets of the token in the query
+    end   int
+}
+
+const (
+    literalToken = "'"
+    dollarToken  = "$$"
+    identToken   = `""`
+)
+
+// lexResult holds the tokens of each statement of a query
+type lexResult struct {
+    statements [][]sqlToken
+    // unterminated is set when the query ends inside a literal, a quoted
+    // identifier, a comment or a dollar-quoted body
+    unterminated bool
+    // ambiguous is set when a literal ends in a different place depending on
+    // the session settings of the server, as described for sqlSpan
+    ambiguous bool
+}
+
+// lexStatements returns the tokens of each statement of query as the engine of d reads it
+func lexStatements(d Dialect, query string) lexResult {
+    x := syntaxFor(d)
+    var res lexResult
+    var current []sqlToken
+    depth := 0
+    block := false
+
+    add := func(text string, word bool, pos, end int) {
+        current = append(current, sqlToken{text: text, word: word, depth: depth, pos: pos, end: end})
+    }
+    addName := func(text string, name string, word bool, pos, end int) {
+        current = append(current, sqlToken{text: text, name: name, word: word, depth: depth, pos: pos, end: end})
+    }
+
+    n := len(query)
+    for i := 0; i < n; {
+        c := query[i]
+        if sp := x.span(query, i); sp.kind != noSpan {
+            res.unterminated = res.unterminated || sp.unterminated
+            res.ambiguous = res.ambiguous || sp.ambiguous
+            switch sp.kind {
+            case literalSpan:
+                add(literalToken, false, i, sp.end)
+            case dollarSpan:
+                add(dollarToken, false, i, sp.end)
+            case identSpan:
+                addName(identToken, identName(query[i:sp.end], sp.unterminated), false, i, sp.end)
+            }
+            i = sp.end
+            continue
+        }
+
+        switch {
+        case c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == '\f':
+            i++
+        case x.wordByte(c):
+            j := i + 1
+            for j < n && x.wordByte(query[j]) {
+                j++
+            }
+            addName(strings.ToUpper(query[i:j]), query[i:j], true, i, j)
+            i = j
+        case c == '(':
+            add("(", false, i, i+1)
+            depth++
+            i++
+        case c == ')':
+            if depth > 0 {
+                depth--
+            }
+            add(")", false, i, i+1)
+            i++
+        case c == ';':
+            i++
+            if block || opensBlock(current) {
+                // El resto del texto pertenece al bloque
+                block = true
+                add(";", false, i-1, i)
+                continue
+            }
+            if len(current) > 0 {
+                res.statements = append(res.statements, current)
+            }
+            current, depth = nil, 0
+        default:
+            add(string(c), false, i, i+1)
+            i++
+        }
+    }
+    if len(current) > 0 {
+        res.statements = append(res.statements, current)
+    }
+    return res
+}
+
+// identName returns the content of a quoted identifier such as "a""b" or [a]]b]
+func identName(quoted string, unterminated bool) string {
+    close := quoted[0]
+    if close == '[' {
+        close = ']'
+    }
+    body := quoted[1:]
+    if !unterminated {
+        body = body[:len(body)-1]
+    }
+    return strings.ReplaceAll(body, string(close)+string(close), string(close))
+}
+
+// isWordByte accepts identifier characters plus the @, # and $ of variables,
+// temporary tables and placeholders, and any byte of a multi-byte character
+func isWordByte(c byte) bool {
+    return isIdentChar(c) || c == '@' || c == '#' || c == '$' || c >= 0x80
+}
+
+// transactionWords may follow BEGIN when it starts a transaction rather than a block
+var transactionWords = map[string]bool{
+    "TRANSACTION": true, "TRAN": true, "WORK": true, "DISTRIBUTED": true,
+    "DEFERRED": true, "IMMEDIATE": true, "EXCLUSIVE": true,
+    "ISOLATION": true, "READ": true, "NOT": true,
+}
+
+// opensBlock reports whether the statement read so far is a procedural block
+// whose semicolons do not end it
+func opensBlock(tokens []sqlToken) bool {
+    if len(tokens) == 0 || !tokens[0].word {
+        return false
+    }
+    switch tokens[0].text {
+    case "DECLARE":
+        return true
+    case "BEGIN":
+        return len(tokens) > 1 && tokens[1].word && !transactionWords[tokens[1].text]
+    case "CREATE":
+        if hasToken(tokens, dollarToken) {
+            // Cuerpo entre $$: el punto y coma que sigue cierra la sentencia
+            return false
+        }
+        routine := false
+        for _, t := range tokens[1:] {
+            switch {
+            case t.word && (t.text == "PROCEDURE" || t.text == "FUNCTION" || t.text == "TRIGGER" || t.text == "PACKAGE" || t.text == "EVENT"):
+                routine = true
+            case routine && t.word && (t.text == "BEGIN" || t.text == "AS" || t.text == "IS"):
+                return true
+            }
+        }
+    }
+    return false
+}
+
+// classifyStatement classifies the tokens of one statement. A read that runs
+// a write in a subquery or a CTE, as in WITH d AS (DELETE ...) SELECT, is a write.
+func classifyStatement(tokens []sqlToken) Statement {
+    st := classifyTokens(tokens)
+    if st.Kind == StatementRead && modifiesInParens(tokens) {
+        st.Kind = StatementWrite
+    }
+    st.Tables = statementTables(tokens)
+    return st
+}
+
+func classifyTokens(tokens []sqlToken) Statement {
+    i := 0
+    for i < len(tokens) && !tokens[i].word {
+        i++
+    }
+    if i == len(tokens) {
+        return Statement{Kind: StatementUnknown}
+    }
+
+    top := tokens[i].depth
+    st := Statement{Kind: StatementUnknown, Verb: tokens[i].text}
+    rest := tokens[i+1:]
+    next := ""
+    if len(rest) > 0 && rest[0].word {
+        next = rest[0].text
+    }
+
+    switch st.Verb {
+    case "WITH":
+        // El verbo principal es la primera palabra clave fuera de las CTE
+        for j, t := range rest {
+            if t.word && t.depth == top && mainVerbs[t.text] {
+                return classifyTokens(rest[j:])
+            }
+        }
+    case "SELECT":
+        st.Kind = StatementRead
+        st.Locking = lockingRead(rest, top)
+        if selectInto(rest, top) {
+            st.Kind = StatementWrite
+        }
+    case "VALUES", "TABLE", "SHOW", "DESCRIBE", "DESC", "HELP":
+        st.Kind = StatementRead
+    case "EXPLAIN":
+        // EXPLAIN ANALYZE ejecuta la sentencia que describe
+        st.Kind = StatementRead
+        analyze := false
+        for j, t := range rest {
+            if t.word && (t.text == "ANALYZE" || t.text == "ANALYSE") {
+                analyze = true
+            }
+            if t.word && mainVerbs[t.text] {
+                if analyze {
+                    st.Kind = classifyTokens(rest[j:]).Kind
+                }
+                break
+            }
+        }
+    case "PRAGMA":
+        st.Kind = pragmaKind(rest)
+    case "INSERT", "UPDATE", "DELETE", "MERGE", "UPSERT", "REPLACE", "LOAD", "LOCK", "HANDLER":
+        st.Kind = StatementWrite
+    case "COPY":
+        // Solo COPY ... TO STDOUT es una lectura; TO 'archivo' y TO PROGRAM escriben en el servidor
+        st.Kind = StatementRead
+        for j, t := range rest {
+            if !t.word || t.depth != top {
+                continue
+            }
+            if t.text == "FROM" || t.text == "TO" && (j+1 == len(rest) || rest[j+1].text != "STDOUT") {
+                st.Kind = StatementWrite
+            }
+        }
+    case "CREATE", "ALTER", "DROP", "TRUNCATE", "RENAME", "COMMENT", "GRANT", "REVOKE",
+        "ANALYZE", "VACUUM", "REINDEX", "CLUSTER", "OPTIMIZE", "REFRESH", "PURGE", "FLASHBACK":
+        st.Kind = StatementDDL
+    case "START", "COMMIT", "ROLLBACK", "SAVEPOINT", "RELEASE", "END", "ABORT", "SAVE", "XA":
+        st.Kind = StatementTransaction
+    case "BEGIN":
+        st.Kind = StatementTransaction
+        if next != "" && !transactionWords[next] {
+            st.Kind = StatementCall
+        }
+    case "SET":
+        st.Kind = StatementSession
+        if next == "TRANSACTION" {
+            st.Kind = StatementTransaction
+        }
+    case "USE", "RESET", "DISCARD", "PREPARE", "DEALLOCATE", "UNLOCK":
+        st.Kind = StatementSession
+    case "CALL", "EXEC", "EXECUTE", "DO", "DECLARE":
+        st.Kind = StatementCall
+    }
+    return st
+}
+
+// readPragmas are the SQLite pragmas that take an argument and only read it
+var readPragmas = map[string]bool{
+    "TABLE_INFO": true, "TABLE_XINFO": true, "INDEX_INFO": true, "INDEX_XINFO": true,
+    "INDEX_LIST": true, "FOREIGN_KEY_LIST": true, "FOREIGN_KEY_CHECK": true,
+    "INTEGRITY_CHECK": true, "QUICK_CHECK": true,
+}
+
+// pragmaKind classifies the tokens after PRAGMA. A pragma given a value, as
+// in PRAGMA query_only = 0 or PRAGMA query_only(0), changes the session unless
+// it is one of the readPragmas.
+func pragmaKind(tokens []sqlToken) StatementKind {
+    for j, t := range tokens {
+        if t.text != "=" && t.text != "(" {
+            continue
+        }
+        if t.text == "(" && j > 0 && readPragmas[tokens[j-1].text] {
+            return StatementRead
+        }
+        return StatementSession
+    }
+    return StatementRead
+}
+
+// mainVerbs are the keywords that can follow the CTEs of a WITH clause
+var mainVerbs = map[string]bool{
+    "SELECT": true, "INSERT": true, "UPDATE": true, "DELETE": true, "MERGE": true,
+    "UPSERT": true, "REPLACE": true, "VALUES": true, "TABLE": true,
+}
+
+// lockingRead spots FOR UPDATE/SHARE, LOCK IN SHARE MODE and the SQL Server lock hints
+func lockingRead(tokens []sqlToken, top int) bool {
+    for j, t := range tokens {
+        if !t.word {
+            continue
+        }
+        switch t.text {
+        case "UPDLOCK", "XLOCK", "HOLDLOCK", "TABLOCKX":
+            return true
+        case "FOR":
+            if t.depth == top && j+1 < len(tokens) {
+                switch tokens[j+1].text {
+                case "UPDATE", "SHARE", "NO", "KEY":
+                    return true
+                }
+            }
+        case "LOCK":
+            if t.depth == top && j+1 < len(tokens) && tokens[j+1].text == "IN" {
+                return true
+            }
+        }
+    }
+    return false
+}
+
+// selectInto reports whether a SELECT stores its rows: INTO a table or a
+// file. SELECT ... INTO @variable or :variable only reads.
+func selectInto(tokens []sqlToken, top int) bool {
+    for j, t := range tokens {
+        if t.word && t.depth == top && t.text == "INTO" {
+            if j+1 == len(tokens) {
+                return true
+            }
+            target := tokens[j+1]
+            return !(target.text == ":" || strings.HasPrefix(target.text, "@"))
+        }
+    }
+    return false
+}
+
+// modifiesInParens reports whether a write statement opens a parenthesis
+func modifiesInParens(tokens []sqlToken) bool {
+    for j := 0; j+1 < len(tokens); j++ {
+        if tokens[j].text == "(" && tokens[j+1].word {
+            switch tokens[j+1].text {
+            case "INSERT", "UPDATE", "DELETE", "MERGE":
+                return true
+            }
+        }
+    }
+    return false
+}
+
+func hasToken(tokens []sqlToken, text string) bool {
+    for _, t := range tokens {
+        if t.text == text {
+            return true
+        }
+    }
+    return false
+}
+
+// fromFunctions take FROM as part of their arguments, as in EXTRACT(YEAR FROM d)
+var fromFunctions = map[string]bool{
+    "EXTRACT": true, "SUBSTRING": true, "TRIM": true, "OVERLAY": true,
+}
+
+// statementTables collects the names that follow FROM, JOIN, INTO, USING,
+// TABLE and the verbs that take a table. The FROM of EXTRACT, SUBSTRING, TRIM
+// and OVERLAY, and names followed by a parenthesis after FROM or JOIN, which
+// are table functions, are skipped.
+func statementTables(tokens []sqlToken) []string {
+    verb := -1
+    for k, t := range tokens {
+        if t.word {
+            verb = k
+            break
+        }
+    }
+    if verb < 0 {
+        return nil
+    }
+
+    ctes := make(map[string]bool)
+    if tokens[verb].text == "WITH" {
+        // Los nombres de las CTE aparecen tras WITH, RECURSIVE o una coma de la lista
+        top := tokens[verb].depth
+        for k := verb + 1; k < len(tokens); k++ {
+            t := tokens[k]
+            if t.word && t.depth == top && mainVerbs[t.text] {
+                break
+            }
+            prev := tokens[k-1]
+            if t.depth == top && t.name != "" && t.text != "RECURSIVE" &&
+                (k == verb+1 || prev.text == "," || prev.text == "RECURSIVE") {
+                ctes[strings.ToLower(t.name)] = true
+            }
+        }
+    }
+
+    var tables []string
+    seen := make(map[string]bool)
+    addTable := func(name string) {
+        if !seen[name] && !ctes[name] {
+            seen[name] = true
+            tables = append(tables, name)
+        }
+    }
+
+    var functionParens []bool
+    for k := 0; k < len(tokens); k++ {
+        t := tokens[k]
+        switch {
+        case t.text == "(":
+            prev := sqlToken{}
+            if k > 0 {
+                prev = tokens[k-1]
+            }
+            functionParens = append(functionParens, prev.word && fromFunctions[prev.text])
+            continue
+        case t.text == ")":
+            if len(functionParens) > 0 {
+                functionParens = functionParens[:len(functionParens)-1]
+            }
+            continue
+        case !t.word:
+            continue
+        }
+
+        inFunction := len(functionParens) > 0 && functionParens[len(functionParens)-1]
+        list := false
+        switch t.text {
+        case "FROM", "JOIN":
+            // COPY t FROM STDIN lee un archivo, no una tabla
+            if inFunction || tokens[verb].text == "COPY" && t.depth == tokens[verb].depth {
+                continue
+            }
+            list = t.text == "FROM"
+        case "INTO", "USING", "TABLE":
+        case "UPDATE", "TRUNCATE", "DESCRIBE", "DESC", "COPY":
+            if k != verb {
+                continue
+            }
+        default:
+            continue
+        }
+
+        for j := k + 1; j < len(tokens); {
+            // IF [NOT] EXISTS, ONLY
+            for j < len(tokens) && tokens[j].word && (tokens[j].text == "IF" || tokens[j].text == "NOT" || tokens[j].text == "EXISTS" || tokens[j].text == "ONLY" || tokens[j].text == "TABLE") {
+                j++
+            }
+            name, next := qualifiedName(tokens, j)
+            if name == "" {
+                break
+            }
+            if next < len(tokens) && tokens[next].text == "(" && (t.text == "FROM" || t.text == "JOIN") {
+                // Función de tabla: se salta su lista de argumentos
+                depth := tokens[next].depth
+                for next++; next < len(tokens) && !(tokens[next].text == ")" && tokens[next].depth == depth); next++ {
+                }
+                next++
+            } else {
+                addTable(name)
+            }
+            if !list {
+                break
+            }
+
+            // FROM a [AS] x, b ...
+            if next < len(tokens) && tokens[next].text == "AS" {
+                next++
+            }
+            if next+1 < len(tokens) && tokens[next].name != "" && tokens[next+1].text == "," {
+                next++
+            }
+            if next >= len(tokens) || tokens[next].text != "," {
+                break
+            }
+            j = next + 1
+        }
+    }
+    return tables
+}
+
+// qualifiedName reads a name such as schema.table starting at tokens[j], in
+// lower case, and returns the index after it. Variables and keywords that
+// open a statement are not names.
+func qualifiedName(tokens []sqlToken, j int) (string, int) {
+    var parts []string
+    for j < len(tokens) && tokens[j].name != "" {
+        t := tokens[j]
+        if t.word && (strings.HasPrefix(t.name, "@") || strings.HasPrefix(t.name, "$") || mainVerbs[t.text]) {
+            break
+        }
+        parts = append(parts, strings.ToLower(t.name))
+        j++
+        if j+1 < len(tokens) && tokens[j].text == "." && tokens[j+1].name != "" {
+            j++
+            continue
+        }
+        break
+    }
+    return strings.Join(parts, "."), j
+}
@@ -0,0 +1,231 @@
+package db
+
+import (
+    "reflect"
+    "testing"
+)
+
+func TestClassifyStatements(t *testing.T) {
+    tests := []struct {
+        name   string
+        driver string
+        query  string
+        want   []Statement
+    }{
+        {
+            name:   "leading comments",
+            driver: "postgres",
+            query:  "-- c\n/* d */ DELETE FROM t WHERE id = 1",
+            want:   []Statement{{Kind: StatementWrite, Verb: "DELETE", Tables: []string{"t"}}},
+        },
+        {
+            name:   "with insert",
+            driver: "postgres",
+            query:  "WITH x AS (SELECT 1) INSERT INTO t SELECT * FROM x",
+            want:   []Statement{{Kind: StatementWrite, Verb: "INSERT", Tables: []string{"t"}}},
+        },
+        {
+            name:   "with select",
+            driver: "postgres",
+            query:  "WITH x AS (SELECT 1) SELECT * FROM x JOIN s.u ON true",
+            want:   []Statement{{Kind: StatementRead, Verb: "SELECT", Tables: []string{"s.u"}}},
+        },
+        {
+            name:   "merge",
+            driver: "sqlserver",
+            query:  "MERGE INTO t USING s ON t.id = s.id WHEN MATCHED THEN DELETE;",
+            want:   []Statement{{Kind: StatementWrite, Verb: "MERGE", Tables: []string{"t", "s"}}},
+        },
+        {
+            name:   "upsert",
+            driver: "sqlite3",
+            query:  "UPSERT INTO t VALUES (1)",
+            want:   []Statement{{Kind: StatementWrite, Verb: "UPSERT", Tables: []string{"t"}}},
+        },
+        {
+            name:   "exec",
+            driver: "sqlserver",
+            query:  "EXEC dbo.p 1",
+            want:   []Statement{{Kind: StatementCall, Verb: "EXEC"}},
+        },
+        {
+            name:   "grant",
+            driver: "postgres",
+            query:  "GRANT SELECT ON t TO u",
+            want:   []Statement{{Kind: StatementDDL, Verb: "GRANT"}},
+        },
+        {
+            name:   "lowercase with newlines",
+            driver: "mysql",
+            query:  "select\n*\nfrom T\nwhere a = 1",
+            want:   []Statement{{Kind: StatementRead, Verb: "SELECT", Tables: []string{"t"}}},
+        },
+        {
+            name:   "batch",
+            driver: "mysql",
+            query:  "SELECT 1; UPDATE t SET a = 1",
+            want: []Statement{
+                {Kind: StatementRead, Verb: "SELECT"},
+                {Kind: StatementWrite, Verb: "UPDATE", Tables: []string{"t"}},
+            },
+        },
+        {
+            name:   "locking read",
+            driver: "postgres",
+            query:  "SELECT * FROM t FOR UPDATE",
+            want:   []Statement{{Kind: StatementRead, Verb: "SELECT", Locking: true, Tables: []string{"t"}}},
+        },
+        {
+            name:   "select into",
+            driver: "sqlserver",
+            query:  "SELECT * INTO n FROM t",
+            want:   []Statement{{Kind: StatementWrite, Verb: "SELECT", Tables: []string{"n", "t"}}},
+        },
+        {
+            name:   "do block",
+            driver: "postgres",
+            query:  "DO $$ BEGIN DELETE FROM t; END $$",
+            want:   []Statement{{Kind: StatementCall, Verb: "DO"}},
+        },
+        {
+            name:   "plsql block",
+            driver: "oracle",
+            query:  "BEGIN DELETE FROM t; COMMIT; END;",
+            want:   []Statement{{Kind: StatementCall, Verb: "BEGIN", Tables: []string{"t"}}},
+        },
+        {
+            name:   "transaction",
+            driver: "postgres",
+            query:  "BEGIN; COMMIT",
+            want: []Statement{
+                {Kind: StatementTransaction, Verb: "BEGIN"},
+                {Kind: StatementTransaction, Verb: "COMMIT"},
+            },
+        },
+        {
+            name:   "session",
+            driver: "sqlite3",
+            query:  "PRAGMA foreign_keys = ON",
+            want:   []Statement{{Kind: StatementSession, Verb: "PRAGMA"}},
+        },
+        {
+            name:   "pragma function form",
+            driver: "sqlite3",
+            query:  "PRAGMA query_only(0); PRAGMA main.writable_schema(1)",
+            want: []Statement{
+                {Kind: StatementSession, Verb: "PRAGMA"},
+                {Kind: StatementSession, Verb: "PRAGMA"},
+            },
+        },
+        {
+            name:   "pragma reads",
+            driver: "sqlite3",
+            query:  "PRAGMA query_only; PRAGMA table_info(t); PRAGMA main.index_list('t')",
+            want: []Statement{
+                {Kind: StatementRead, Verb: "PRAGMA"},
+                {Kind: StatementRead, Verb: "PRAGMA"},
+                {Kind: StatementRead, Verb: "PRAGMA"},
+            },
+        },
+        {
+            name:   "copy from stdin",
+            driver: "postgres",
+            query:  "COPY t FROM STDIN",
+            want:   []Statement{{Kind: StatementWrite, Verb: "COPY", Tables: []string{"t"}}},
+        },
+        {
+            name:   "copy to stdout",
+            driver: "postgres",
+            query:  "COPY s.t (a, b) TO STDOUT",
+            want:   []Statement{{Kind: StatementRead, Verb: "COPY", Tables: []string{"s.t"}}},
+        },
+        {
+            name:   "copy to a file",
+            driver: "postgres",
+            query:  "COPY t TO '/tmp/t.csv'",
+            want:   []Statement{{Kind: StatementWrite, Verb: "COPY", Tables: []string{"t"}}},
+        },
+        {
+            name:   "copy to a program",
+            driver: "postgres",
+            query:  "COPY (SELECT * FROM t) TO PROGRAM 'gzip > /tmp/t.gz'",
+            want:   []Statement{{Kind: StatementWrite, Verb: "COPY", Tables: []string{"t"}}},
+        },
+        {
+            name:   "empty",
+            driver: "sqlite3",
+            query:  " -- nada\n",
+            want:   nil,
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got := ClassifyStatements(dialectFor(tt.driver), tt.query)
+            if !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("ClassifyStatements(%q)\n got %+v\nwant %+v", tt.query, got, tt.want)
+            }
+        })
+    }
+}
+
+func TestClassifyStatement(t *testing.T) {
+    tests := []struct {
+        query string
+        want  StatementKind
+    }{
+        {"SELECT 1", StatementRead},
+        {"SELECT 1; DROP TABLE t", StatementDDL},
+        {"BEGIN; UPDATE t SET a = 1; COMMIT", StatementWrite},
+        {"", StatementUnknown},
+    }
+
+    for _, tt := range tests {
+        if got := ClassifyStatement(dialectFor("postgres"), tt.query); got != tt.want {
+            t.Errorf("ClassifyStatement(%q) = %s, want %s", tt.query, got, tt.want)
+        }
+    }
+}
+
+// TestLexStatements covers the texts that end a literal or a comment in a
+// different place on each engine
+func TestLexStatements(t *testing.T) {
+    tests := []struct {
+        name         string
+        driver       string
+        query        string
+        statements   int
+        unterminated bool
+        ambiguous    bool
+    }{
+        {"mysql backslash quote", "mysql", `SELECT '\''; DROP TABLE t; -- '`, 2, false, true},
+        {"mysql doubled quote", "mysql", `SELECT 'O''Brien', '\\'`, 1, false, false},
+        {"mysql hash comment", "mysql", "SELECT 1 # '\n; DROP TABLE t; -- '", 2, false, false},
+        {"mysql double dash needs a blank", "mysql", "SELECT 1 --1; DROP TABLE t", 2, false, false},
+        {"mysql version comment", "mysql", "/*!50000 DROP TABLE t */", 1, false, false},
+        {"generic mysql", "", "SELECT 1 # x\n; DROP TABLE t", 2, false, false},
+        {"postgres escape string", "postgres", `SELECT E'\''; SET default_transaction_read_only = off; DROP TABLE t; -- '`, 3, false, false},
+        {"postgres backslash", "postgres", `SELECT '\'; DROP TABLE t; -- '`, 2, false, true},
+        {"postgres subscript", "postgres", "SELECT arr[1]; DROP TABLE t; -- ]", 2, false, false},
+        {"postgres nested comment", "postgres", "SELECT /* a /* b */ ; DROP TABLE t; */ 1", 1, false, false},
+        {"postgres dollar quote", "postgres", "SELECT $x$ ; $$ ; $x$", 1, false, false},
+        {"sqlserver bracket", "sqlserver", "SELECT [a]]; DROP TABLE t; --] FROM x", 1, false, false},
+        {"oracle q quote", "oracle", "SELECT q'[it's]' FROM dual; DROP TABLE t", 2, false, false},
+        {"unterminated literal", "sqlite3", "SELECT 'abc", 1, true, false},
+        {"unterminated comment", "postgres", "SELECT 1 /* a /* b */", 1, true, false},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            d := dialectFor(tt.driver)
+            if tt.driver == "" {
+                d = GenericDialect{Driver: "mysql"}
+            }
+            got := lexStatements(d, tt.query)
+            if len(got.statements) != tt.statements || got.unterminated != tt.unterminated || got.ambiguous != tt.ambiguous {
+                t.Errorf("lexStatements(%q) = %d statements, unterminated %v, ambiguous %v; want %d, %v, %v",
+                    tt.query, len(got.statements), got.unterminated, got.ambiguous, tt.statements, tt.unterminated, tt.ambiguous)
+            }
+        })
+    }
+}
@@ -0,0 +1,482 @@
+package db
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "reflect"
+    "regexp"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/godror/godror"
+
+    ARGS "github.com/IngenieroRicardo/db/ARGS"
+    ENCODER "github.com/IngenieroRicardo/db/ENCODER"
+    STRC "github.com/IngenieroRicardo/db/STRUCTURES"
+)
+
+// CopyDialect is implemented by dialects that can create the target table of
+// a copy. GenericDialect provides SQL-standard types, so every dialect that
+// embeds it has one.
+type CopyDialect interface {
+    // ColumnType returns the type of the target column for a source column
+    ColumnType(column CopyColumn) string
+}
+
+// CopyColumn describes a column of the source rows to the target dialect
+type CopyColumn struct {
+    Name        string
+    Type        string // database type name on the source, in upper case
+    Kind        ENCODER.ValueKind
+    Binary      bool
+    Approximate bool  // a floating-point number
+    Length      int64 // of text and binary columns; 0 when unknown or unbounded
+    Precision   int64 // of exact numbers; 0 when unknown
+    Scale       int64
+    TimeZone    bool // a time that keeps its offset
+    Nullable    bool
+}
+
+// CopyOptions describes how CopyTable and CopyQuery write the target
+type CopyOptions struct {
+    // CreateTable creates the target table from the source columns, without
+    // keys or indexes, before the first row is written
+    CreateTable bool
+    // ChunkRows is how many rows are written per transaction, DefaultCopyChunk when zero or less
+    ChunkRows int
+    // Progress, when set, is called after every chunk with the rows copied so far
+    Progress func(rows int64)
+}
+
+// DefaultCopyChunk is the number of rows written per transaction by default
+const DefaultCopyChunk = 1000
+
+var (
+    // plainIdentifier is a column name that can go unquoted on every engine
+    plainIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+    // approximateTypes are the floating-point column types
+    approximateTypes = map[string]bool{
+        "FLOAT": true, "DOUBLE": true, "DOUBLE PRECISION": true, "REAL": true,
+        "FLOAT4": true, "FLOAT8": true, "BINARY_FLOAT": true, "BINARY_DOUBLE": true,
+    }
+)
+
+// CopyTable copies every row of sourceTable on source into targetTable on
+// target, which may run a different engine. targetTable defaults to
+// sourceTable. See CopyQuery.
+func CopyTable(source *Connector, sourceTable string, target *Connector, targetTable string, opts CopyOptions) STRC.InternalResult {
+    if !bulkTable.MatchString(sourceTable) {
+        return bulkError(fmt.Sprintf("nombre de tabla no válido: %q", sourceTable))
+    }
+    if targetTable == "" {
+        targetTable = sourceTable
+    }
+    return CopyQuery(source, "SELECT * FROM "+sourceTable, target, targetTable, opts)
+}
+
+// CopyQuery runs query on source and writes its rows into targetTable on
+// target, ChunkRows at a time through the bulk path of the target engine.
+// Values are converted by the kind of their source column, so a text number
+// read from one driver is written as a number to the other. Each chunk is a
+// transaction of its own: when one fails, the chunks before it stay written.
+// The result reports the rows copied as {"status":"OK","rows":N,"elapsed_ms":...}.
+func CopyQuery(source *Connector, query string, target *Connector, targetTable string, opts CopyOptions, args ...string) STRC.InternalResult {
+    return CopyQueryContext(context.Background(), source, query, target, targetTable, opts, args...)
+}
+
+// CopyQueryContext is CopyQuery bounded by ctx; the statement timeout of
+// target bounds every chunk
+func CopyQueryContext(ctx context.Context, source *Connector, query string, target *Connector, targetTable string, opts CopyOptions, args ...string) STRC.InternalResult {
+    if !bulkTable.MatchString(targetTable) {
+        return bulkError(fmt.Sprintf("nombre de tabla no válido: %q", targetTable))
+    }
+    goArgs, err := ARGS.Parse(args)
+    if err != nil {
+        return errorResult(ctx, source.dialect, "", err)
+    }
+
+    // Los hooks de source ven la copia entera como la consulta de origen
+    return observe(ctx, hooksFor(source), source.driver, query, goArgs, func() STRC.InternalResult {
+        return copyQuery(ctx, source, query, goArgs, target, targetTable, opts)
+    })
+}
+
+// copyQuery reads the rows of query on source and loads them into targetTable
+func copyQuery(ctx context.Context, source *Connector, query string, goArgs []any, target *Connector, targetTable string, opts CopyOptions) STRC.InternalResult {
+    start := time.Now()
+    cursor, err := openCursor(ctx, source, query, goArgs)
+    if err != nil {
+        return errorResult(ctx, source.dialect, "", err)
+    }
+    defer CloseCursor(cursor)
+
+    columns := copyColumns(source.dialect, cursor.writer)
+    names := make([]string, len(columns))
+    for i, column := range columns {
+        names[i] = quoteColumn(target.dialect, column.Name)
+        if !bulkColumn.MatchString(names[i]) {
+            return bulkError(fmt.Sprintf("nombre de columna no válido: %q", column.Name))
+        }
+    }
+
+    var create string
+    if opts.CreateTable {
+        create = createTableSQL(target.dialect, targetTable, names, columns)
+        if err := target.allows(create); err != nil {
+            return errorResult(ctx, target.dialect, "", err)
+        }
+    }
+    if err := target.allows(insertValuesSQL(target.dialect, targetTable, names, 1)); err != nil {
+        return errorResult(ctx, target.dialect, "", err)
+    }
+
+    db, err := target.handle()
+    if err != nil {
+        return bulkError(err.Error())
+    }
+    // Los bloques escritos se quedan aunque falle uno posterior
+    defer target.cachedResults().invalidate(targetTable)
+
+    if create != "" {
+        createCtx, cancel := statementContext(ctx, target)
+        _, err := db.ExecContext(createCtx, create)
+        cancel()
+        if err != nil {
+            return errorResult(ctx, target.dialect, "error al crear la tabla", err)
+        }
+    }
+
+    chunkRows := opts.ChunkRows
+    if chunkRows <= 0 {
+        chunkRows = DefaultCopyChunk
+    }
+    values := make([]any, len(columns))
+    scan := make([]any, len(columns))
+    for i := range values {
+        scan[i] = &values[i]
+    }
+
+    var total int64
+    chunk := make([][]any, 0, chunkRows)
+    flush := func() error {
+        if len(chunk) == 0 {
+            return nil
+        }
+        chunkCtx, cancel := statementContext(ctx, target)
+        defer cancel()
+        if err := loadRows(chunkCtx, db, target.dialect, targetTable, names, chunk); err != nil {
+            return err
+        }
+        total += int64(len(chunk))
+        chunk = chunk[:0]
+        if opts.Progress != nil {
+            opts.Progress(total)
+        }
+        return nil
+    }
+
+    // Solo se copia el primer resultset
+    for cursor.rows.Next() {
+        if err := cursor.rows.Scan(scan...); err != nil {
+            return errorResult(ctx, source.dialect, "Error al escanear fila", err)
+        }
+        row := make([]any, len(columns))
+        for i, column := range columns {
+            row[i] = copyValue(column, values[i])
+        }
+        chunk = append(chunk, row)
+        if len(chunk) == chunkRows {
+            if err := flush(); err != nil {
+                return errorResult(ctx, target.dialect, "", err)
+            }
+        }
+    }
+    if err := cursor.rows.Err(); err != nil {
+        return errorResult(ctx, source.dialect, "Error después de iterar filas", err)
+    }
+    if err := flush(); err != nil {
+        return errorResult(ctx, target.dialect, "", err)
+    }
+    jsonData, _ := json.Marshal(STRC.SuccessResponse{
+        Status:    "OK",
+        Rows:      total,
+        ElapsedMs: elapsedMs(time.Since(start)),
+    })
+    return STRC.InternalResult{
+        Json:     string(jsonData),
+        Is_error: 0,
+        Is_empty: boolToInt(total == 0),
+        Rows:     total,
+    }
+}
+
+// copyColumns describes the columns of the current result set of w
+func copyColumns(d Dialect, w *rowWriter) []CopyColumn {
+    columns := make([]CopyColumn, len(w.columns))
+    for i, colType := range w.colTypes {
+        typeName := strings.ToUpper(colType.DatabaseTypeName())
+        baseType := typeName
+        if j := strings.Index(baseType, "("); j > 0 {
+            baseType = strings.TrimSpace(baseType[:j])
+        }
+
+        column := CopyColumn{
+            Name:     w.columns[i],
+            Type:     typeName,
+            Kind:     ENCODER.Kind(colType),
+            Binary:   d.IsBinaryColumn(typeName),
+            TimeZone: strings.Contains(typeName, "TZ") || strings.Contains(typeName, "TIME ZONE") || strings.Contains(typeName, "OFFSET"),
+            Nullable: true,
+        }
+        if column.Kind == ENCODER.KindDecimal {
+            column.Approximate = approximateTypes[baseType] || (baseType == "" && colType.ScanType() != nil && colType.ScanType().Kind() == reflect.Float64)
+        }
+        if length, ok := colType.Length(); ok && length > 0 && length < 1<<31 {
+            column.Length = length
+        }
+        if precision, scale, ok := colType.DecimalSize(); ok && precision > 0 && scale >= 0 && scale <= precision {
+            column.Precision, column.Scale = precision, scale
+        }
+        if nullable, ok := colType.Nullable(); ok {
+            column.Nullable = nullable
+        }
+        columns[i] = column
+    }
+    return columns
+}
+
+// copyValue converts a value read from the source into the Go type the
+// target drivers expect for its column kind. Text that does not parse is
+// written as read.
+func copyValue(column CopyColumn, v any) any {
+    var s string
+    switch v := v.(type) {
+    case nil:
+        return nil
+    case []byte:
+        if column.Binary {
+            return v
+        }
+        s = string(v)
+    case godror.Number:
+        s = string(v)
+    case string:
+        s = v
+    case int64:
+        if column.Kind == ENCODER.KindBool {
+            return v != 0
+        }
+        return v
+    default:
+        return v
+    }
+
+    switch column.Kind {
+    case ENCODER.KindInteger:
+        if n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64); err == nil {
+            return n
+        }
+    case ENCODER.KindDecimal:
+        if column.Approximate {
+            if f, err := strconv.ParseFloat(strings.TrimSpace(s), 64); err == nil {
+                return f
+            }
+        }
+    case ENCODER.KindBool:
+        if b, ok := ENCODER.ParseBool([]byte(s)); ok {
+            return b
+        }
+    case ENCODER.KindTime:
+        if t, ok := ENCODER.ParseTime(s); ok {
+            return t
+        }
+    }
+    return s
+}
+
+// quoteColumn leaves plain names unquoted, so the target folds them to its
+// own case, and quotes the rest the way d does
+func quoteColumn(d Dialect, name string) string {
+    if plainIdentifier.MatchString(name) {
+        return name
+    }
+    switch d.(type) {
+    case mysqlDialect:
+        return "`" + name + "`"
+    case sqlserverDialect:
+        return "[" + name + "]"
+    }
+    return `"` + name + `"`
+}
+
+func createTableSQL(d Dialect, table string, names []string, columns []CopyColumn) string {
+    cd, ok := d.(CopyDialect)
+    if !ok {
+        cd = GenericDialect{}
+    }
+
+    var sb strings.Builder
+    sb.WriteString("CREATE TABLE ")
+    sb.WriteString(table)
+    sb.WriteString(" (")
+    for i, column := range columns {
+        if i > 0 {
+            sb.WriteString(", ")
+        }
+        sb.WriteString(names[i])
+        sb.WriteString(" ")
+        sb.WriteString(cd.ColumnType(column))
+        if !column.Nullable {
+            sb.WriteString(" NOT NULL")
+        }
+    }
+    sb.WriteString(")")
+    return sb.String()
+}
+
+// exactType returns name(precision,scale) when the precision is known and
+// fits in maxPrecision, and fallback otherwise
+func exactType(column CopyColumn, name string, maxPrecision int64, fallback string) string {
+    if column.Precision == 0 || column.Precision > maxPrecision {
+        return fallback
+    }
+    return fmt.Sprintf("%s(%d,%d)", name, column.Precision, column.Scale)
+}
+
+// sizedType returns name(length) when the length is known and fits in
+// maxLength, and fallback otherwise
+func sizedType(column CopyColumn, name string, maxLength int64, fallback string) string {
+    if column.Length == 0 || column.Length > maxLength {
+        return fallback
+    }
+    return fmt.Sprintf("%s(%d)", name, column.Length)
+}
+
+func (GenericDialect) ColumnType(column CopyColumn) string {
+    switch {
+    case column.Binary:
+        return "BLOB"
+    case column.Kind == ENCODER.KindInteger:
+        return "BIGINT"
+    case column.Kind == ENCODER.KindDecimal && column.Approximate:
+        return "DOUBLE PRECISION"
+    case column.Kind == ENCODER.KindDecimal:
+        return exactType(column, "DECIMAL", 38, "DECIMAL")
+    case column.Kind == ENCODER.KindBool:
+        return "BOOLEAN"
+    case column.Kind == ENCODER.KindTime && column.TimeZone:
+        return "TIMESTAMP WITH TIME ZONE"
+    case column.Kind == ENCODER.KindTime:
+        return "TIMESTAMP"
+    }
+    return sizedType(column, "VARCHAR", 4000, "TEXT")
+}
+
+// SQLite only keeps the affinity, but mattn/go-sqlite3 reads BOOLEAN and
+// TIMESTAMP columns back as bool and time.Time
+func (sqliteDialect) ColumnType(column CopyColumn) string {
+    switch {
+    case column.Binary:
+        return "BLOB"
+    case column.Kind == ENCODER.KindInteger:
+        return "INTEGER"
+    case column.Kind == ENCODER.KindDecimal && column.Approximate:
+        return "REAL"
+    case column.Kind == ENCODER.KindDecimal:
+        return "NUMERIC"
+    case column.Kind == ENCODER.KindBool:
+        return "BOOLEAN"
+    case column.Kind == ENCODER.KindTime:
+        return "TIMESTAMP"
+    }
+    return "TEXT"
+}
+
+func (mysqlDialect) ColumnType(column CopyColumn) string {
+    switch {
+    case column.Binary:
+        return "LONGBLOB"
+    case column.Kind == ENCODER.KindInteger:
+        return "BIGINT"
+    case column.Kind == ENCODER.KindDecimal && column.Approximate:
+        return "DOUBLE"
+    case column.Kind == ENCODER.KindDecimal:
+        return exactType(column, "DECIMAL", 65, "DECIMAL(65,30)")
+    case column.Kind == ENCODER.KindBool:
+        return "BOOLEAN"
+    case column.Kind == ENCODER.KindTime:
+        return "DATETIME(6)"
+    case column.Kind == ENCODER.KindJSON:
+        return "JSON"
+    }
+    return sizedType(column, "VARCHAR", 16383, "LONGTEXT")
+}
+
+func (postgresDialect) ColumnType(column CopyColumn) string {
+    switch {
+    case column.Binary:
+        return "BYTEA"
+    case column.Kind == ENCODER.KindInteger:
+        return "BIGINT"
+    case column.Kind == ENCODER.KindDecimal && column.Approximate:
+        return "DOUBLE PRECISION"
+    case column.Kind == ENCODER.KindDecimal:
+        return exactType(column, "NUMERIC", 1000, "NUMERIC")
+    case column.Kind == ENCODER.KindBool:
+        return "BOOLEAN"
+    case column.Kind == ENCODER.KindTime && column.TimeZone:
+        return "TIMESTAMPTZ"
+    case column.Kind == ENCODER.KindTime:
+        return "TIMESTAMP"
+    case column.Kind == ENCODER.KindJSON:
+        return "JSONB"
+    }
+    return sizedType(column, "VARCHAR", 10485760, "TEXT")
+}
+
+func (sqlserverDialect) ColumnType(column CopyColumn) string {
+    switch {
+    case column.Binary:
+        return sizedType(column, "VARBINARY", 8000, "VARBINARY(MAX)")
+    case column.Kind == ENCODER.KindInteger:
+        return "BIGINT"
+    case column.Kind == ENCODER.KindDecimal && column.Approximate:
+        return "FLOAT"
+    case column.Kind == ENCODER.KindDecimal:
+        return exactType(column, "DECIMAL", 38, "DECIMAL(38,10)")
+    case column.Kind == ENCODER.KindBool:
+        return "BIT"
+    case column.Kind == ENCODER.KindTime && column.TimeZone:
+        return "DATETIMEOFFSET"
+    case column.Kind == ENCODER.KindTime:
+        return "DATETIME2"
+    }
+    return sizedType(column, "NVARCHAR", 4000, "NVARCHAR(MAX)")
+}
+
+func (oracleDialect) ColumnType(column CopyColumn) string {
+    switch {
+    case column.Binary:
+        return "BLOB"
+    case column.Kind == ENCODER.KindInteger:
+        return "NUMBER(19)"
+    case column.Kind == ENCODER.KindDecimal && column.Approximate:
+        return "BINARY_DOUBLE"
+    case column.Kind == ENCODER.KindDecimal:
+        return exactType(column, "NUMBER", 38, "NUMBER")
+    case column.Kind == ENCODER.KindBool:
+        return "NUMBER(1)"
+    case column.Kind == ENCODER.KindTime && column.TimeZone:
+        return "TIMESTAMP WITH TIME ZONE"
+    case column.Kind == ENCODER.KindTime:
+        return "TIMESTAMP"
+    case column.Kind == ENCODER.KindJSON:
+        return "CLOB"
+    }
+    if column.Length == 0 || column.Length > 4000 {
+        return "CLOB"
+    }
+    return fmt.Sprintf("VARCHAR2(%d CHAR)", column.Length)
+}
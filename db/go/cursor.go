@@ -0,0 +1,216 @@
+package db
+
+import (
+    "bytes"
+    "context"
+    "database/sql"
+    "fmt"
+    "io"
+    "sync"
+
+    ARGS "github.com/IngenieroRicardo/db/ARGS"
+    STRC "github.com/IngenieroRicardo/db/STRUCTURES"
+)
+
+// Cursor keeps a result set open so it can be read in chunks with FetchNext.
+// Cursors and streams are not bounded by the connector statement timeout
+// because they are expected to outlive a single statement; pass a context to
+// OpenCursorContext or StreamNDJSONContext to limit them.
+type Cursor struct {
+    mu     sync.Mutex
+    ctx    context.Context
+    cancel context.CancelFunc
+    rows   *sql.Rows
+    writer *rowWriter
+    done   bool
+}
+
+// OpenCursor runs the query and leaves its rows ready to be fetched
+func OpenCursor(connector *Connector, query string, args ...string) (*Cursor, error) {
+    return OpenCursorContext(context.Background(), connector, query, args...)
+}
+
+// OpenCursorContext is OpenCursor bounded by ctx for the whole life of the cursor
+func OpenCursorContext(ctx context.Context, connector *Connector, query string, args ...string) (*Cursor, error) {
+    goArgs, err := ARGS.Parse(args)
+    if err != nil {
+        return nil, err
+    }
+
+    var cursor *Cursor
+    _, err = observeRows(ctx, connector, query, goArgs, func() (int64, error) {
+        cursor, err = openCursor(ctx, connector, query, goArgs)
+        return 0, err
+    })
+    return cursor, err
+}
+
+// openCursor runs the query without reporting it to the hooks, for the
+// entry points that observe the whole read
+func openCursor(ctx context.Context, connector *Connector, query string, goArgs []any) (*Cursor, error) {
+    if err := connector.allows(query); err != nil {
+        return nil, err
+    }
+    query, goArgs, err := bindNamed(connector.dialect, query, goArgs)
+    if err != nil {
+        return nil, err
+    }
+
+    db, err := connector.handle()
+    if err != nil {
+        return nil, err
+    }
+
+    ctx, cancel := context.WithCancel(ctx)
+    rows, err := db.QueryContext(ctx, query, goArgs...)
+    if err != nil {
+        cancel()
+        return nil, fmt.Errorf("Error en la consulta SQL: %w", err)
+    }
+
+    w, err := newRowWriter(rows, connector.dialect, connector.outputMode(ctx))
+    if err != nil {
+        rows.Close()
+        cancel()
+        return nil, err
+    }
+
+    return &Cursor{ctx: ctx, cancel: cancel, rows: rows, writer: w}, nil
+}
+
+// FetchNext returns up to n rows (all remaining rows when n <= 0) as a JSON
+// array. Once every result set is exhausted it returns "[]" with Is_empty = 1.
+func FetchNext(cursor *Cursor, n int) STRC.InternalResult {
+    cursor.mu.Lock()
+    defer cursor.mu.Unlock()
+
+    var buf bytes.Buffer
+    rowCount := 0
+
+    buf.WriteString("[")
+    for !cursor.done && (n <= 0 || rowCount < n) {
+        if !cursor.rows.Next() {
+            if err := cursor.rows.Err(); err != nil {
+                cursor.done = true
+                return errorResult(cursor.ctx, cursor.writer.dialect, "Error después de iterar filas", err)
+            }
+            // Continuamos con el siguiente resultset si existe
+            if !cursor.rows.NextResultSet() {
+                cursor.done = true
+                break
+            }
+            w, err := newRowWriter(cursor.rows, cursor.writer.dialect, cursor.writer.mode)
+            if err != nil {
+                cursor.done = true
+                return STRC.InternalResult{
+                    Json:     createErrorJSON(err.Error()),
+                    Is_error: 1,
+                    Is_empty: 0,
+                }
+            }
+            cursor.writer = w
+            continue
+        }
+
+        if err := cursor.rows.Scan(cursor.writer.values...); err != nil {
+            return errorResult(cursor.ctx, cursor.writer.dialect, "Error al escanear fila", err)
+        }
+        if rowCount > 0 {
+            buf.WriteString(",")
+        }
+        if err := cursor.writer.write(&buf); err != nil {
+            return STRC.InternalResult{
+                Json:     createErrorJSON(err.Error()),
+                Is_error: 1,
+                Is_empty: 0,
+            }
+        }
+        rowCount++
+    }
+    buf.WriteString("]")
+
+    if rowCount == 0 {
+        return STRC.InternalResult{
+            Json:     "[]",
+            Is_error: 0,
+            Is_empty: 1,
+        }
+    }
+    return STRC.InternalResult{
+        Json:     buf.String(),
+        Is_error: 0,
+        Is_empty: 0,
+    }
+}
+
+// CloseCursor releases the rows and the connection held by the cursor
+func CloseCursor(cursor *Cursor) error {
+    cursor.mu.Lock()
+    defer cursor.mu.Unlock()
+
+    cursor.done = true
+    err := cursor.rows.Close()
+    cursor.cancel()
+    return err
+}
+
+// StreamNDJSON runs the query and writes every row to w as one JSON object
+// per line, without holding the result set in memory. Each row is handed to
+// w in a single Write call. It returns the number of rows written.
+func StreamNDJSON(connector *Connector, w io.Writer, query string, args ...string) (int64, error) {
+    return StreamNDJSONContext(context.Background(), connector, w, query, args...)
+}
+
+// StreamNDJSONContext is StreamNDJSON bounded by ctx
+func StreamNDJSONContext(ctx context.Context, connector *Connector, w io.Writer, query string, args ...string) (int64, error) {
+    goArgs, err := ARGS.Parse(args)
+    if err != nil {
+        return 0, err
+    }
+
+    return observeRows(ctx, connector, query, goArgs, func() (int64, error) {
+        cursor, err := openCursor(ctx, connector, query, goArgs)
+        if err != nil {
+            return 0, err
+        }
+        defer CloseCursor(cursor)
+
+        return streamNDJSON(cursor, w)
+    })
+}
+
+// streamNDJSON writes the remaining rows of cursor to w, one JSON object per line
+func streamNDJSON(cursor *Cursor, w io.Writer) (int64, error) {
+    var total int64
+    var line bytes.Buffer
+    for {
+        if !cursor.rows.Next() {
+            if err := cursor.rows.Err(); err != nil {
+                return total, fmt.Errorf("Error después de iterar filas: %w", err)
+            }
+            if !cursor.rows.NextResultSet() {
+                return total, nil
+            }
+            rw, err := newRowWriter(cursor.rows, cursor.writer.dialect, cursor.writer.mode)
+            if err != nil {
+                return total, err
+            }
+            cursor.writer = rw
+            continue
+        }
+
+        if err := cursor.rows.Scan(cursor.writer.values...); err != nil {
+            return total, fmt.Errorf("Error al escanear fila: %w", err)
+        }
+
+        line.Reset()
+        if err := cursor.writer.write(&line); err != nil {
+            return total, err
+        }
+        line.WriteByte('\n')
+        if _, err := w.Write(line.Bytes()); err != nil {
+            return total, fmt.Errorf("error al escribir fila %d: %w", total+1, err)
+        }
+        total++
+    }
+}
@@ -1,27 +1,80 @@
 package db
 
 import (
-	"encoding/base64"
+	"context"
 	"encoding/json"
-	"fmt"
-	"strconv"
-	"strings"
+	ARGS "github.com/IngenieroRicardo/db/ARGS"
 	STRC "github.com/IngenieroRicardo/db/STRUCTURES"
-	LDB "github.com/IngenieroRicardo/db/LDB"
-	MDB "github.com/IngenieroRicardo/db/MDB"
-	PDB "github.com/IngenieroRicardo/db/PDB"
-	SDB "github.com/IngenieroRicardo/db/SDB"
-	ODB "github.com/IngenieroRicardo/db/ODB"
+	ENCODER "github.com/IngenieroRicardo/db/ENCODER"
 	"database/sql"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 // Connector represents a database connection
 type Connector struct {
-    db      *sql.DB
-    driver  string
-    //mu      sync.Mutex
+    db            *sql.DB
+    driver        string
+    conexion      string
+    dialect       Dialect
+    stmts         *stmtCache
+    stats         *queryStats
+    settings      poolSettings
+    nextReplica   atomic.Uint64 // round-robin position among replicas
+    mu            sync.RWMutex  // guards db, settings and the fields below
+    dead          bool          // closed by the health checker, reopened on next use
+    closed        bool          // closed by CloseSQL
+    results       *resultCache  // nil unless EnableResultCache was called
+    mode          ENCODER.Mode  // see SetTypedJSON
+    envelope      bool          // see SetResultEnvelope
+    timeout       time.Duration // see SetStatementTimeout
+    reconnects    int64
+    replicas      []*Connector           // reads are balanced across them, see LoadSQLWithReplicas
+    pinned        bool                   // every statement goes to the primary, see PinPrimary
+    hooks         []Hook                 // see AddHook
+    readOnly      bool                   // see SetReadOnly
+    allowedKinds  map[StatementKind]bool // nil allows every kind, see AllowStatementKinds
+    allowedTables map[string]bool        // nil allows every table, see AllowTables
+}
+
+// poolSettings are the LoadSQL pool limits, kept to reapply them on reconnect
+type poolSettings struct {
+    maxOpenConns    int
+    maxIdleConns    int
+    connMaxLifetime time.Duration
+    connMaxIdleTime time.Duration
+}
+
+// merge keeps the current value of every setting left at zero
+func (p *poolSettings) merge(maxOpenConns, maxIdleConns int, connMaxLifetime, connMaxIdleTime time.Duration) {
+    if maxOpenConns > 0 {
+        p.maxOpenConns = maxOpenConns
+    }
+    if maxIdleConns > 0 {
+        p.maxIdleConns = maxIdleConns
+    }
+    if connMaxLifetime > 0 {
+        p.connMaxLifetime = connMaxLifetime
+    }
+    if connMaxIdleTime > 0 {
+        p.connMaxIdleTime = connMaxIdleTime
+    }
+}
+
+func (p poolSettings) apply(db *sql.DB) {
+    if p.maxOpenConns > 0 {
+        db.SetMaxOpenConns(p.maxOpenConns)
+    }
+    if p.maxIdleConns > 0 {
+        db.SetMaxIdleConns(p.maxIdleConns)
+    }
+    if p.connMaxLifetime > 0 {
+        db.SetConnMaxLifetime(p.connMaxLifetime)
+    }
+    if p.connMaxIdleTime > 0 {
+        db.SetConnMaxIdleTime(p.connMaxIdleTime)
+    }
 }
 
 // connectionPool stores active connections
@@ -40,139 +93,175 @@ func LoadSQL(driver string, conexion string, maxOpenConns, maxIdleConns int, con
     
     key := driver + ":" + conexion
     if conn, exists := connectionPool.connections[key]; exists {
-        if maxOpenConns > 0 {
-            conn.db.SetMaxOpenConns(maxOpenConns)
-        }
-        if maxIdleConns > 0 {
-            conn.db.SetMaxIdleConns(maxIdleConns)
-        }
-        if connMaxLifetime > 0 {
-            conn.db.SetConnMaxLifetime(connMaxLifetime)
-        }
-        if connMaxIdleTime > 0 {
-            conn.db.SetConnMaxIdleTime(connMaxIdleTime)
+        conn.mu.Lock()
+        conn.settings.merge(maxOpenConns, maxIdleConns, connMaxLifetime, connMaxIdleTime)
+        if !conn.dead {
+            conn.settings.apply(conn.db)
         }
+        conn.mu.Unlock()
         return conn, nil
     }
     
-    var db *sql.DB
-    var err error
-    
-    switch driver {
-    case "sqlite3":
-        db, err = LDB.OpenConnection(driver, conexion)
-    case "sqlserver":
-        db, err = SDB.OpenConnection(driver, conexion)
-    case "postgres":
-        db, err = PDB.OpenConnection(driver, conexion)
-    case "oracle":
-        db, err = ODB.OpenConnection("godror", conexion)
-    default:
-        db, err = MDB.OpenConnection(driver, conexion)
-    }
+    dialect := dialectFor(driver)
+    db, err := openConnection(dialect, conexion)
     
     if err != nil {
         return nil, err
     }
 
-    if maxOpenConns > 0 {
-        db.SetMaxOpenConns(maxOpenConns)
-    }
-    if maxIdleConns > 0 {
-        db.SetMaxIdleConns(maxIdleConns)
-    }
-    if connMaxLifetime > 0 {
-        db.SetConnMaxLifetime(connMaxLifetime)
-    }
-    if connMaxIdleTime > 0 {
-        db.SetConnMaxIdleTime(connMaxIdleTime)
-    }
-    
     connector := &Connector{
-        db:     db,
-        driver: driver,
+        db:       db,
+        driver:   driver,
+        conexion: conexion,
+        dialect:  dialect,
+        stmts:    newStmtCache(dialect, DefaultStatementCacheSize),
+        stats:    newQueryStats(),
     }
+    connector.settings.merge(maxOpenConns, maxIdleConns, connMaxLifetime, connMaxIdleTime)
+    connector.settings.apply(db)
     
     connectionPool.connections[key] = connector
     return connector, nil
 }
 
+type modeKey struct{}
+
+// SetTypedJSON switches the connector between typed JSON output (numbers,
+// booleans, RFC3339 timestamps, nested JSON) and the all-strings output.
+// LoadSQL hands the same pooled connector to every caller of a driver and
+// connection string, so the setting reaches all of them; WithTypedJSON
+// chooses the output of a single call instead.
+func SetTypedJSON(connector *Connector, typed bool) {
+    mode := ENCODER.Strings
+    if typed {
+        mode = ENCODER.Typed
+    }
+    connector.mu.Lock()
+    defer connector.mu.Unlock()
+    connector.mode = mode
+}
+
+// WithTypedJSON returns a context whose queries on a preloaded connector
+// answer with typed JSON values, or with strings when typed is false,
+// whatever SetTypedJSON chose for the connector
+func WithTypedJSON(ctx context.Context, typed bool) context.Context {
+    mode := ENCODER.Strings
+    if typed {
+        mode = ENCODER.Typed
+    }
+    return context.WithValue(ctx, modeKey{}, mode)
+}
+
+// outputMode returns the JSON mode asked for by ctx or set on the connector
+func (c *Connector) outputMode(ctx context.Context) ENCODER.Mode {
+    if mode, ok := ctx.Value(modeKey{}).(ENCODER.Mode); ok {
+        return mode
+    }
+    c.mu.RLock()
+    defer c.mu.RUnlock()
+    return c.mode
+}
+
+// SetStatementTimeout sets the default time limit for every statement run on
+// the connector. Zero disables it; a shorter deadline on the caller context wins.
+func SetStatementTimeout(connector *Connector, timeout time.Duration) {
+    connector.mu.Lock()
+    defer connector.mu.Unlock()
+    connector.timeout = timeout
+}
+
+// statementContext applies the connector default timeout and result shape to ctx
+func statementContext(ctx context.Context, connector *Connector) (context.Context, context.CancelFunc) {
+    connector.mu.RLock()
+    envelope, timeout := connector.envelope, connector.timeout
+    connector.mu.RUnlock()
+    if envelope {
+        ctx = WithResultEnvelope(ctx)
+    }
+    if timeout > 0 {
+        return context.WithTimeout(ctx, timeout)
+    }
+    return context.WithCancel(ctx)
+}
+
 // SQLrunonLoad executes a query using a preloaded connection
 func SQLrunonLoad(connector *Connector, query string, args ...string) STRC.InternalResult {
+    return SQLrunonLoadContext(context.Background(), connector, query, args...)
+}
+
+// SQLrunonLoadContext executes a query using a preloaded connection, bounded by
+// ctx and the connector statement timeout
+func SQLrunonLoadContext(ctx context.Context, connector *Connector, query string, args ...string) STRC.InternalResult {
     //connector.mu.Lock()
     //defer connector.mu.Unlock()
     
-    var goArgs []interface{}
-    var result STRC.InternalResult
-
-    // Process each argument
-    for _, arg := range args {
-        switch {
-        case strings.HasPrefix(arg, "int::"):
-            intVal, err := strconv.ParseInt(arg[5:], 10, 64)
-            if err != nil {
-                result.Json = createErrorJSON(fmt.Sprintf("Error parseando entero: %s", arg[5:]))
-                result.Is_error = 1
-                return result
-            }
-            goArgs = append(goArgs, intVal)
-
-        case strings.HasPrefix(arg, "float::"), strings.HasPrefix(arg, "double::"):
-            prefixLen := 7
-            if strings.HasPrefix(arg, "double::") {
-                prefixLen = 8
-            }
-            floatVal, err := strconv.ParseFloat(arg[prefixLen:], 64)
-            if err != nil {
-                result.Json = createErrorJSON(fmt.Sprintf("Error parseando float: %s", arg[prefixLen:]))
-                result.Is_error = 1
-                return result
-            }
-            goArgs = append(goArgs, floatVal)
-
-        case strings.HasPrefix(arg, "bool::"):
-            boolVal, err := strconv.ParseBool(arg[6:])
-            if err != nil {
-                result.Json = createErrorJSON(fmt.Sprintf("Error parseando booleano: %s", arg[6:]))
-                result.Is_error = 1
-                return result
-            }
-            goArgs = append(goArgs, boolVal)
-
-        case strings.HasPrefix(arg, "null::"):
-            goArgs = append(goArgs, nil)
-
-        case strings.HasPrefix(arg, "blob::"):
-            data, err := base64.StdEncoding.DecodeString(arg[6:])
-            if err != nil {
-                result.Json = createErrorJSON(fmt.Sprintf("Error decodificando blob: %v", err))
-                result.Is_error = 1
-                return result
-            }
-            goArgs = append(goArgs, data)
-
-        default:
-            goArgs = append(goArgs, arg)
+    goArgs, err := ARGS.Parse(args)
+    if err != nil {
+        return STRC.InternalResult{
+            Json:     createErrorJSON(err.Error()),
+            Is_error: 1,
+            Is_empty: 0,
+        }
+    }
+
+    return observe(ctx, hooksFor(connector), connector.driver, query, goArgs, func() STRC.InternalResult {
+        return runOnLoad(ctx, connector, query, goArgs)
+    })
+}
+
+// runOnLoad routes a parsed statement and runs it through the connector caches
+func runOnLoad(ctx context.Context, connector *Connector, query string, goArgs []any) STRC.InternalResult {
+    if err := connector.allows(query); err != nil {
+        return errorResult(ctx, connector.dialect, "", err)
+    }
+
+    target := connector.route(ctx, query)
+    db, err := target.handle()
+    if err != nil {
+        return STRC.InternalResult{
+            Json:     createErrorJSON(err.Error()),
+            Is_error: 1,
+            Is_empty: 0,
+        }
+    }
+
+    ctx, cancel := statementContext(ctx, connector)
+    defer cancel()
+
+    mode := connector.outputMode(ctx)
+    cache, key, read := connector.cachedResults(), "", isReadStatement(connector.dialect, query)
+    var generation uint64
+    if cache != nil && read {
+        key = resultKey(ctx, mode, query, goArgs)
+        if result, ok := cache.get(key); ok {
+            return result
         }
+        generation = cache.currentGeneration()
     }
 
-    // Execute based on driver
-    switch connector.driver {
-    case "sqlite3":
-        return LDB.SqlRunOnConn(connector.db, query, goArgs...)
-    case "sqlserver":
-        return SDB.SqlRunOnConn(connector.db, query, goArgs...)
-    case "postgres":
-        return PDB.SqlRunOnConn(connector.db, query, goArgs...)
-    case "oracle":
-        return ODB.SqlRunOnConn(connector.db, query, goArgs...)
-    default:
-        return MDB.SqlRunOnConn(connector.db, query, goArgs...)
+    start := time.Now()
+    result := runOnConn(ctx, cachedConn{db, target.stmts}, connector.dialect, mode, query, goArgs...)
+    target.stats.record(time.Since(start), result.Is_error != 0)
+
+    if cache != nil {
+        if !read {
+            // Aunque falle, el motor pudo aplicar parte de la escritura
+            cache.invalidateWrite(connector.dialect, query)
+        } else if result.Is_error == 0 {
+            cache.put(ctx, key, query, result, generation)
+        }
     }
+    return result
 }
 
-// CloseSQL closes a connection and removes it from the pool
+// CloseSQL closes a connection and removes it from the pool. Its replicas
+// are detached but stay open: they are pooled connectors that LoadSQL, or
+// another primary, may have handed out too. Close them with CloseSQL.
 func CloseSQL(connector *Connector) error {
+    connector.mu.Lock()
+    connector.replicas = nil
+    connector.mu.Unlock()
+
     connectionPool.Lock()
     defer connectionPool.Unlock()
     
@@ -183,7 +272,17 @@ func CloseSQL(connector *Connector) error {
             break
         }
     }
-    
+
+    connector.mu.Lock()
+    defer connector.mu.Unlock()
+
+    // Los statements preparados pertenecen a este *sql.DB
+    connector.stmts.clear()
+    connector.closed = true
+    if connector.dead {
+        // El health checker ya lo cerró
+        return nil
+    }
     return connector.db.Close()
 }
 
@@ -193,74 +292,33 @@ func CloseSQL(connector *Connector) error {
 
 
 
+// SQLrun opens a one-shot connection, executes a query and returns all-strings JSON
 func SQLrun(driver string, conexion string, query string, args ...string) STRC.InternalResult {
-	var result STRC.InternalResult
-	var goArgs []interface{}
-
-	// Procesar cada argumento
-	for _, arg := range args {
-		switch {
-		case strings.HasPrefix(arg, "int::"):
-			intVal, err := strconv.ParseInt(arg[5:], 10, 64)
-			if err != nil {
-				result.Json = createErrorJSON(fmt.Sprintf("Error parseando entero: %s", arg[5:]))
-				result.Is_error = 1
-				return result
-			}
-			goArgs = append(goArgs, intVal)
-
-		case strings.HasPrefix(arg, "float::"), strings.HasPrefix(arg, "double::"):
-			prefixLen := 7
-			if strings.HasPrefix(arg, "double::") {
-				prefixLen = 8
-			}
-			floatVal, err := strconv.ParseFloat(arg[prefixLen:], 64)
-			if err != nil {
-				result.Json = createErrorJSON(fmt.Sprintf("Error parseando float: %s", arg[prefixLen:]))
-				result.Is_error = 1
-				return result
-			}
-			goArgs = append(goArgs, floatVal)
-
-		case strings.HasPrefix(arg, "bool::"):
-			boolVal, err := strconv.ParseBool(arg[6:])
-			if err != nil {
-				result.Json = createErrorJSON(fmt.Sprintf("Error parseando booleano: %s", arg[6:]))
-				result.Is_error = 1
-				return result
-			}
-			goArgs = append(goArgs, boolVal)
-
-		case strings.HasPrefix(arg, "null::"):
-			goArgs = append(goArgs, nil)
-
-		case strings.HasPrefix(arg, "blob::"):
-			data, err := base64.StdEncoding.DecodeString(arg[6:])
-			if err != nil {
-				result.Json = createErrorJSON(fmt.Sprintf("Error decodificando blob: %v", err))
-				result.Is_error = 1
-				return result
-			}
-			goArgs = append(goArgs, data)
-
-		default:
-			goArgs = append(goArgs, arg)
+	return sqlRun(context.Background(), ENCODER.Strings, driver, conexion, query, args...)
+}
+
+// SQLrunTyped is SQLrun returning typed JSON values
+func SQLrunTyped(driver string, conexion string, query string, args ...string) STRC.InternalResult {
+	return sqlRun(context.Background(), ENCODER.Typed, driver, conexion, query, args...)
+}
+
+// SQLrunContext is SQLrun bounded by ctx; a cancelled or expired context is
+// reported with ErrorCodeTimeout or ErrorCodeCanceled
+func SQLrunContext(ctx context.Context, driver string, conexion string, query string, args ...string) STRC.InternalResult {
+	return sqlRun(ctx, ENCODER.Strings, driver, conexion, query, args...)
+}
+
+func sqlRun(ctx context.Context, mode ENCODER.Mode, driver string, conexion string, query string, args ...string) STRC.InternalResult {
+	goArgs, err := ARGS.Parse(args)
+	if err != nil {
+		return STRC.InternalResult{
+			Json:     createErrorJSON(err.Error()),
+			Is_error: 1,
+			Is_empty: 0,
 		}
 	}
 
-	// Ejecutar según el driver
-	switch driver {
-	case "sqlite3":
-		return LDB.SqlRunInternal(driver, conexion, query, goArgs...)
-	case "sqlserver":
-		return SDB.SqlRunInternal(driver, conexion, query, goArgs...)
-	case "postgres":
-		return PDB.SqlRunInternal(driver, conexion, query, goArgs...)
-	case "oracle":
-		return ODB.SqlRunInternal("godror", conexion, query, goArgs...)
-	default:
-		return MDB.SqlRunInternal(driver, conexion, query, goArgs...)
-	}
+	return SqlRunInternalContext(ctx, driver, conexion, mode, query, goArgs...)
 }
 
 func createErrorJSON(message string) string {
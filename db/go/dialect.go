@@ -0,0 +1,176 @@
+package db
+
+import (
+    "fmt"
+    "strings"
+    "sync"
+
+    _ "github.com/denisenkom/go-mssqldb"
+    _ "github.com/go-sql-driver/mysql"
+    _ "github.com/godror/godror"
+    _ "github.com/lib/pq"
+    _ "github.com/mattn/go-sqlite3"
+)
+
+// Dialect describes the engine-specific details the execution engine needs
+type Dialect interface {
+    // DriverName is the database/sql driver name passed to sql.Open
+    DriverName() string
+    // IsBinaryColumn reports whether a column type is returned base64-encoded
+    IsBinaryColumn(typeName string) bool
+    // Placeholder returns the positional marker for the n-th argument (1-based)
+    Placeholder(n int) string
+    // CallProcedure builds the invocation of a stored procedure
+    CallProcedure(name string, placeholders []string) string
+    // SelectFunction builds the invocation of a scalar function, alias may be empty
+    SelectFunction(name string, placeholders []string, alias string) string
+    // IsNonReturning reports whether a statement produces no result set
+    IsNonReturning(query string) bool
+}
+
+// dialects stores the registered dialects by the driver name used in LoadSQL/SQLrun
+var dialects = struct {
+    sync.RWMutex
+    byName map[string]Dialect
+}{
+    byName: make(map[string]Dialect),
+}
+
+func init() {
+    RegisterDialect("sqlite3", sqliteDialect{GenericDialect{Driver: "sqlite3"}})
+    RegisterDialect("mysql", mysqlDialect{GenericDialect{Driver: "mysql"}})
+    RegisterDialect("postgres", postgresDialect{GenericDialect{Driver: "postgres"}})
+    RegisterDialect("sqlserver", sqlserverDialect{GenericDialect{Driver: "sqlserver"}})
+    RegisterDialect("oracle", oracleDialect{GenericDialect{Driver: "godror"}})
+    RegisterDialect("godror", oracleDialect{GenericDialect{Driver: "godror"}})
+}
+
+// RegisterDialect makes a dialect available under name for LoadSQL and SQLrun.
+// Registering an existing name replaces the previous dialect.
+func RegisterDialect(name string, d Dialect) {
+    dialects.Lock()
+    defer dialects.Unlock()
+    dialects.byName[name] = d
+}
+
+// dialectFor returns the dialect registered for driver. Unknown drivers fall
+// back to GenericDialect opened with the given driver name.
+func dialectFor(driver string) Dialect {
+    dialects.RLock()
+    defer dialects.RUnlock()
+    if d, ok := dialects.byName[driver]; ok {
+        return d
+    }
+    return GenericDialect{Driver: driver}
+}
+
+// GenericDialect implements Dialect with `?` placeholders and CALL syntax
+// (MySQL/SQLite behaviour). Embed it to override only what differs.
+type GenericDialect struct {
+    Driver string
+}
+
+func (g GenericDialect) DriverName() string {
+    return g.Driver
+}
+
+func (g GenericDialect) IsBinaryColumn(typeName string) bool {
+    return strings.Contains(typeName, "BLOB")
+}
+
+func (g GenericDialect) Placeholder(n int) string {
+    return "?"
+}
+
+func (g GenericDialect) CallProcedure(name string, placeholders []string) string {
+    return fmt.Sprintf("CALL %s(%s)", name, strings.Join(placeholders, ","))
+}
+
+func (g GenericDialect) SelectFunction(name string, placeholders []string, alias string) string {
+    if alias != "" {
+        return fmt.Sprintf("SELECT %s(%s) AS %s", name, strings.Join(placeholders, ","), alias)
+    }
+    return fmt.Sprintf("SELECT %s(%s)", name, strings.Join(placeholders, ","))
+}
+
+// IsNonReturning is true when no statement of query is a read: writes, DDL,
+// transaction control, session commands and procedure calls. A write whose
+// main verb is SELECT, such as WITH d AS (DELETE ...) SELECT, or an EXPLAIN
+// ANALYZE of a write still returns rows.
+func (g GenericDialect) IsNonReturning(query string) bool {
+    statements := ClassifyStatements(g, query)
+    for _, st := range statements {
+        switch {
+        case st.Kind == StatementRead, st.Kind == StatementUnknown:
+            return false
+        case st.Verb == "SELECT", st.Verb == "VALUES", st.Verb == "TABLE", st.Verb == "EXPLAIN":
+            return false
+        }
+    }
+    return len(statements) > 0
+}
+
+// sqliteDialect keeps the generic syntax and reads its catalog through PRAGMA functions
+type sqliteDialect struct {
+    GenericDialect
+}
+
+// mysqlDialect keeps the generic syntax and reads its catalog from information_schema
+type mysqlDialect struct {
+    GenericDialect
+}
+
+// postgresDialect uses $n placeholders and returns BYTEA as base64
+type postgresDialect struct {
+    GenericDialect
+}
+
+func (postgresDialect) IsBinaryColumn(typeName string) bool {
+    return strings.Contains(typeName, "BYTEA")
+}
+
+func (postgresDialect) Placeholder(n int) string {
+    return fmt.Sprintf("$%d", n)
+}
+
+// sqlserverDialect uses @pN placeholders and EXEC for procedures
+type sqlserverDialect struct {
+    GenericDialect
+}
+
+func (sqlserverDialect) IsBinaryColumn(typeName string) bool {
+    return strings.Contains(typeName, "VARBINARY") || strings.Contains(typeName, "BINARY") || strings.Contains(typeName, "IMAGE")
+}
+
+func (sqlserverDialect) Placeholder(n int) string {
+    return fmt.Sprintf("@p%d", n)
+}
+
+func (sqlserverDialect) CallProcedure(name string, placeholders []string) string {
+    return fmt.Sprintf("EXEC %s %s", name, strings.Join(placeholders, ","))
+}
+
+// oracleDialect uses :n placeholders, anonymous PL/SQL blocks for procedures
+// and FROM DUAL for scalar functions
+type oracleDialect struct {
+    GenericDialect
+}
+
+func (oracleDialect) IsBinaryColumn(typeName string) bool {
+    return strings.Contains(typeName, "BLOB") || strings.Contains(typeName, "RAW") || strings.Contains(typeName, "LONG RAW") || strings.Contains(typeName, "BFILE")
+}
+
+func (oracleDialect) Placeholder(n int) string {
+    return fmt.Sprintf(":%d", n)
+}
+
+func (oracleDialect) CallProcedure(name string, placeholders []string) string {
+    return fmt.Sprintf("BEGIN %s(%s); END;", name, strings.Join(placeholders, ","))
+}
+
+func (oracleDialect) SelectFunction(name string, placeholders []string, alias string) string {
+    if alias != "" {
+        return fmt.Sprintf("SELECT %s(%s) AS %s FROM DUAL", name, strings.Join(placeholders, ","), alias)
+    }
+    return fmt.Sprintf("SELECT %s(%s) FROM DUAL", name, strings.Join(placeholders, ","))
+}
@@ -0,0 +1,351 @@
+package db
+
+import (
+    "bytes"
+    "context"
+    "database/sql"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "strings"
+
+    ARGS "github.com/IngenieroRicardo/db/ARGS"
+    ENCODER "github.com/IngenieroRicardo/db/ENCODER"
+    STRC "github.com/IngenieroRicardo/db/STRUCTURES"
+)
+
+// Codes reported in the "code" field of the error JSON when the context ends
+// the query or the connector rejects it
+const (
+    ErrorCodeTimeout  = "TIMEOUT"
+    ErrorCodeCanceled = "CANCELED"
+    ErrorCodeRejected = "REJECTED"
+)
+
+// OpenConnection opens and pings a connection using the dialect registered for driver
+func OpenConnection(driver, conexion string) (*sql.DB, error) {
+    return openConnection(dialectFor(driver), conexion)
+}
+
+func openConnection(d Dialect, conexion string, session ...string) (*sql.DB, error) {
+    var db *sql.DB
+    var err error
+    if len(session) > 0 {
+        db, err = openSession(d, conexion, session)
+    } else {
+        db, err = sql.Open(d.DriverName(), conexion)
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    err = db.Ping()
+    if err != nil {
+        db.Close()
+        return nil, err
+    }
+
+    return db, nil
+}
+
+// SqlRunOnConn executes a query on an existing connection using the dialect registered for driver
+func SqlRunOnConn(db *sql.DB, driver string, mode ENCODER.Mode, query string, args ...any) STRC.InternalResult {
+    return runOnConn(context.Background(), db, dialectFor(driver), mode, query, args...)
+}
+
+// SqlRunInternal opens a connection, executes a query and closes it
+func SqlRunInternal(driver, conexion string, mode ENCODER.Mode, query string, args ...any) STRC.InternalResult {
+    return SqlRunInternalContext(context.Background(), driver, conexion, mode, query, args...)
+}
+
+// SqlRunInternalContext is SqlRunInternal bounded by ctx
+func SqlRunInternalContext(ctx context.Context, driver, conexion string, mode ENCODER.Mode, query string, args ...any) STRC.InternalResult {
+    return observe(ctx, hooksFor(nil), driver, query, args, func() STRC.InternalResult {
+        return sqlRunInternal(ctx, driver, conexion, mode, query, args...)
+    })
+}
+
+func sqlRunInternal(ctx context.Context, driver, conexion string, mode ENCODER.Mode, query string, args ...any) STRC.InternalResult {
+    d := dialectFor(driver)
+
+    db, err := sql.Open(d.DriverName(), conexion)
+    if err != nil {
+        return STRC.InternalResult{
+            Json:     createErrorJSON(fmt.Sprintf("Error al abrir conexión: %v", err)),
+            Is_error: 1,
+            Is_empty: 0,
+        }
+    }
+    defer db.Close()
+
+    err = db.PingContext(ctx)
+    if err != nil {
+        return errorResult(ctx, d, "Error al conectar a la base de datos", err)
+    }
+
+    return runOnConn(ctx, db, d, mode, query, args...)
+}
+
+// execer is satisfied by *sql.DB and *sql.Tx so the engine runs on either
+type execer interface {
+    QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+    ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+    PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+}
+
+// txBeginner is implemented by the execers that are not already inside a
+// transaction, so batches can open their own
+type txBeginner interface {
+    BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+// runOnConn is the single execution path shared by every dialect. A single
+// ARGS.Named argument binds the :name and @name parameters of query; without
+// it those markers reach the server as written.
+func runOnConn(ctx context.Context, q execer, d Dialect, mode ENCODER.Mode, query string, args ...any) STRC.InternalResult {
+    if len(args) == 1 { //solo un argumento
+        if sjson, ok := args[0].(string); ok { //ese argumento debe ser string
+            //validamos la query pida como input: json[col1,col2,blob(col3),etc..]
+            if jsonRegex.MatchString(query) {
+                if isJSON(sjson) { // validamos el unico argumento string sea un json valido
+                    return runJSONQuery(ctx, q, d, query, sjson)
+                }
+                return STRC.InternalResult{
+                    Json:     createErrorJSON("El query esperaba un JSON valido"),
+                    Is_error: 1,
+                    Is_empty: 0,
+                }
+            }
+        }
+        //parámetros :nombre o @nombre tomados de un objeto JSON, o de cada elemento de un array
+        if params, ok := args[0].(ARGS.Named); ok {
+            namedQuery, names := parseNamed(d, query)
+            if len(names) == 0 {
+                return STRC.InternalResult{
+                    Json:     createErrorJSON("la consulta no tiene parámetros :nombre ni @nombre"),
+                    Is_error: 1,
+                    Is_empty: 0,
+                }
+            }
+            return runNamed(ctx, q, d, mode, namedQuery, names, string(params))
+        }
+    }
+    for _, arg := range args {
+        if _, ok := arg.(ARGS.Named); ok {
+            return STRC.InternalResult{
+                Json:     createErrorJSON("named:: debe ser el único argumento de la consulta"),
+                Is_error: 1,
+                Is_empty: 0,
+            }
+        }
+    }
+
+    return runStatement(ctx, q, d, mode, query, args...)
+}
+
+// runStatement runs a plain statement: writes through Exec, everything else through Query
+func runStatement(ctx context.Context, q execer, d Dialect, mode ENCODER.Mode, query string, args ...any) STRC.InternalResult {
+    if isWriteStatement(d, query) {
+        return runExec(ctx, q, d, mode, query, args...)
+    }
+    if envelopeRequested(ctx) {
+        return runEnvelope(ctx, q, d, mode, query, args...)
+    }
+
+    rows, err := q.QueryContext(ctx, query, args...)
+    if err != nil {
+        return errorResult(ctx, d, "Error en la consulta SQL", err)
+    }
+    defer rows.Close()
+
+    return buildResult(ctx, rows, d, mode, query)
+}
+
+// buildResult turns every result set of rows into the JSON returned to callers
+func buildResult(ctx context.Context, rows *sql.Rows, d Dialect, mode ENCODER.Mode, query string) STRC.InternalResult {
+    var resultsets []string
+    resultSetCount := 0
+    var total int64
+
+    for {
+        w, err := newRowWriter(rows, d, mode)
+        if err != nil {
+            return STRC.InternalResult{
+                Json:     createErrorJSON(err.Error()),
+                Is_error: 1,
+                Is_empty: 0,
+            }
+        }
+
+        var buf bytes.Buffer
+        rowCount, result, ok := writeResultSet(ctx, rows, w, &buf)
+        if !ok {
+            return result
+        }
+        total += int64(rowCount)
+
+        // Solo agregamos el resultset si tiene filas o es el primer resultset
+        if rowCount > 0 || resultSetCount == 0 {
+            resultsets = append(resultsets, buf.String())
+            resultSetCount++
+        }
+
+        // Pasamos al siguiente resultset si existe
+        if !rows.NextResultSet() {
+            break
+        }
+    }
+
+    // Construimos la respuesta final
+    if len(resultsets) > 1 {
+        // Para múltiples resultsets, los combinamos en un array JSON
+        combined := "[" + strings.Join(resultsets, ",") + "]"
+        return STRC.InternalResult{
+            Json:     combined,
+            Is_error: 0,
+            Is_empty: 0,
+            Rows:     total,
+        }
+    } else if strings.Contains(resultsets[0], ":") {
+        return STRC.InternalResult{
+            Json:     resultsets[0],
+            Is_error: 0,
+            Is_empty: 0,
+            Rows:     total,
+        }
+    } else if d.IsNonReturning(query) {
+        return STRC.InternalResult{
+            Json:     createSuccessJSON(),
+            Is_error: 0,
+            Is_empty: 1,
+        }
+    } else {
+        return STRC.InternalResult{
+            Json:     "[]",
+            Is_error: 0,
+            Is_empty: 1,
+        }
+    }
+}
+
+// writeResultSet encodes the rows of the current result set into buf as a
+// JSON array and returns how many it wrote
+func writeResultSet(ctx context.Context, rows *sql.Rows, w *rowWriter, buf *bytes.Buffer) (int, STRC.InternalResult, bool) {
+    rowCount := 0
+
+    buf.WriteString("[")
+
+    for rows.Next() {
+        if rowCount > 0 {
+            buf.WriteString(",")
+        }
+
+        if err := rows.Scan(w.values...); err != nil {
+            return 0, errorResult(ctx, w.dialect, "Error al escanear fila", err), false
+        }
+
+        if err := w.write(buf); err != nil {
+            return 0, STRC.InternalResult{
+                Json:     createErrorJSON(err.Error()),
+                Is_error: 1,
+                Is_empty: 0,
+            }, false
+        }
+        rowCount++
+    }
+
+    buf.WriteString("]")
+
+    if err := rows.Err(); err != nil {
+        return 0, errorResult(ctx, w.dialect, "Error después de iterar filas", err), false
+    }
+    return rowCount, STRC.InternalResult{}, true
+}
+
+// rowWriter encodes the rows of one result set into JSON objects
+type rowWriter struct {
+    dialect   Dialect
+    mode      ENCODER.Mode
+    columns   []string
+    colTypes  []*sql.ColumnType
+    values    []interface{}
+    jsonField int // índice de la columna llamada "JSON", -1 si no existe
+}
+
+// newRowWriter reads the column metadata of the current result set
+func newRowWriter(rows *sql.Rows, d Dialect, mode ENCODER.Mode) (*rowWriter, error) {
+    columns, err := rows.Columns()
+    if err != nil {
+        return nil, fmt.Errorf("Error al obtener columnas: %v", err)
+    }
+
+    colTypes, err := rows.ColumnTypes()
+    if err != nil {
+        return nil, fmt.Errorf("Error al obtener tipos de columna: %v", err)
+    }
+
+    w := &rowWriter{
+        dialect:   d,
+        mode:      mode,
+        columns:   columns,
+        colTypes:  colTypes,
+        values:    make([]interface{}, len(columns)),
+        jsonField: -1,
+    }
+    for i := range w.values {
+        w.values[i] = new(sql.RawBytes)
+    }
+
+    // Verificar si hay un campo llamado "JSON" (case insensitive)
+    for i, col := range columns {
+        if strings.ToUpper(col) == "JSON" {
+            w.jsonField = i
+            break
+        }
+    }
+
+    return w, nil
+}
+
+// write encodes the row last scanned into w.values
+func (w *rowWriter) write(buf *bytes.Buffer) error {
+    if w.jsonField < 0 {
+        // Comportamiento normal para todas las columnas
+        ENCODER.WriteRow(buf, w.mode, w.columns, w.colTypes, w.values, w.dialect.IsBinaryColumn)
+        return nil
+    }
+
+    // Si hay un campo JSON, usamos solo ese campo
+    rb := *(w.values[w.jsonField].(*sql.RawBytes))
+    if rb == nil {
+        buf.WriteString("null")
+        return nil
+    }
+    // Validamos que sea un JSON válido
+    if !json.Valid(rb) {
+        return errors.New("El campo JSON no contiene un JSON válido")
+    }
+    buf.Write(rb)
+    return nil
+}
+
+// errorResult builds the error JSON for err, prefixed with message when it is
+// not empty, including the structured details described by describeError
+func errorResult(ctx context.Context, d Dialect, message string, err error) STRC.InternalResult {
+    resp := describeError(ctx, d, err)
+    if resp.Code == "" && message != "" {
+        resp.Error = fmt.Sprintf("%s: %v", message, err)
+    }
+    jsonData, _ := json.Marshal(resp)
+    return STRC.InternalResult{
+        Json:     string(jsonData),
+        Is_error: 1,
+        Is_empty: 0,
+    }
+}
+
+func createSuccessJSON() string {
+    successResp := STRC.SuccessResponse{Status: "OK"}
+    jsonData, _ := json.Marshal(successResp)
+    return string(jsonData)
+}
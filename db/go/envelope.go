@@ -0,0 +1,155 @@
+package db
+
+import (
+    "bytes"
+    "context"
+    "database/sql"
+    "encoding/json"
+    "strings"
+
+    "github.com/golang-sql/sqlexp"
+
+    ENCODER "github.com/IngenieroRicardo/db/ENCODER"
+    STRC "github.com/IngenieroRicardo/db/STRUCTURES"
+)
+
+type envelopeKey struct{}
+
+// WithResultEnvelope returns a context whose queries answer with the result
+// set envelope: {"resultsets":[{"columns":[...],"rows":[...]}],"messages":[...]}.
+// Every result set is kept in order, empty ones included.
+func WithResultEnvelope(ctx context.Context) context.Context {
+    return context.WithValue(ctx, envelopeKey{}, true)
+}
+
+// SetResultEnvelope makes every query run on the connector answer with the
+// result set envelope described in WithResultEnvelope. The pooled connector is
+// shared by every caller of its driver and connection string; to change the
+// shape of a single call, pass a context from WithResultEnvelope instead.
+func SetResultEnvelope(connector *Connector, enabled bool) {
+    connector.mu.Lock()
+    defer connector.mu.Unlock()
+    connector.envelope = enabled
+}
+
+func envelopeRequested(ctx context.Context) bool {
+    return ctx.Value(envelopeKey{}) != nil
+}
+
+// runEnvelope runs a query and returns all its result sets in the envelope.
+// SQL Server also reports the PRINT and low severity RAISERROR messages.
+func runEnvelope(ctx context.Context, q execer, d Dialect, mode ENCODER.Mode, query string, args ...any) STRC.InternalResult {
+    if _, ok := d.(sqlserverDialect); ok {
+        return runEnvelopeMessages(ctx, q, d, mode, query, args...)
+    }
+
+    rows, err := q.QueryContext(ctx, query, args...)
+    if err != nil {
+        return errorResult(ctx, d, "Error en la consulta SQL", err)
+    }
+    defer rows.Close()
+
+    envelope := STRC.ResultEnvelope{Resultsets: []STRC.ResultSet{}, Messages: []string{}}
+    var total int64
+    for {
+        set, count, result, ok := envelopeSet(ctx, rows, d, mode)
+        if !ok {
+            return result
+        }
+        envelope.Resultsets = append(envelope.Resultsets, set)
+        total += int64(count)
+
+        if !rows.NextResultSet() {
+            break
+        }
+    }
+    if err := rows.Err(); err != nil {
+        return errorResult(ctx, d, "Error después de iterar filas", err)
+    }
+
+    return envelopeResult(envelope, total)
+}
+
+// runEnvelopeMessages follows the sqlexp message loop, which interleaves the
+// result sets with the informational messages sent by the server
+func runEnvelopeMessages(ctx context.Context, q execer, d Dialect, mode ENCODER.Mode, query string, args ...any) STRC.InternalResult {
+    messages := &sqlexp.ReturnMessage{}
+    rows, err := q.QueryContext(ctx, query, append(args, messages)...)
+    if err != nil {
+        return errorResult(ctx, d, "Error en la consulta SQL", err)
+    }
+    defer rows.Close()
+
+    envelope := STRC.ResultEnvelope{Resultsets: []STRC.ResultSet{}, Messages: []string{}}
+    var total int64
+    for active := true; active; {
+        switch m := messages.Message(ctx).(type) {
+        case sqlexp.MsgNotice:
+            envelope.Messages = append(envelope.Messages, m.Message.String())
+        case sqlexp.MsgNext:
+            set, count, result, ok := envelopeSet(ctx, rows, d, mode)
+            if !ok {
+                return result
+            }
+            envelope.Resultsets = append(envelope.Resultsets, set)
+            total += int64(count)
+        case sqlexp.MsgNextResultSet:
+            active = rows.NextResultSet()
+        case sqlexp.MsgError:
+            return errorResult(ctx, d, "Error en la consulta SQL", m.Error)
+        }
+    }
+    if err := ctx.Err(); err != nil {
+        return errorResult(ctx, d, "", err)
+    }
+
+    return envelopeResult(envelope, total)
+}
+
+// envelopeSet reads the current result set with its column metadata and
+// returns how many rows it has
+func envelopeSet(ctx context.Context, rows *sql.Rows, d Dialect, mode ENCODER.Mode) (STRC.ResultSet, int, STRC.InternalResult, bool) {
+    w, err := newRowWriter(rows, d, mode)
+    if err != nil {
+        return STRC.ResultSet{}, 0, STRC.InternalResult{
+            Json:     createErrorJSON(err.Error()),
+            Is_error: 1,
+            Is_empty: 0,
+        }, false
+    }
+
+    columns := make([]STRC.ResultColumn, len(w.colTypes))
+    for i, colType := range w.colTypes {
+        columns[i] = STRC.ResultColumn{Name: colType.Name(), Type: colType.DatabaseTypeName()}
+        if nullable, ok := colType.Nullable(); ok {
+            columns[i].Nullable = &nullable
+        }
+    }
+
+    var buf bytes.Buffer
+    count, result, ok := writeResultSet(ctx, rows, w, &buf)
+    if !ok {
+        return STRC.ResultSet{}, 0, result, false
+    }
+    return STRC.ResultSet{Columns: columns, Rows: json.RawMessage(buf.Bytes())}, count, STRC.InternalResult{}, true
+}
+
+func envelopeResult(envelope STRC.ResultEnvelope, rows int64) STRC.InternalResult {
+    empty := 1
+    for _, set := range envelope.Resultsets {
+        if len(set.Rows) > 2 {
+            empty = 0
+        }
+    }
+
+    var buf bytes.Buffer
+    encoder := json.NewEncoder(&buf)
+    encoder.SetEscapeHTML(false)
+    encoder.Encode(envelope)
+    return STRC.InternalResult{
+        Json:     strings.TrimSuffix(buf.String(), "\n"),
+        Is_error: 0,
+        Is_empty: empty,
+        Rows:     rows,
+    }
+}
@@ -0,0 +1,348 @@
+package db
+
+import (
+    "context"
+    "database/sql/driver"
+    "errors"
+    "net"
+    "regexp"
+    "strconv"
+    "strings"
+
+    mssql "github.com/denisenkom/go-mssqldb"
+    "github.com/go-sql-driver/mysql"
+    "github.com/godror/godror"
+    "github.com/lib/pq"
+    "github.com/mattn/go-sqlite3"
+
+    STRC "github.com/IngenieroRicardo/db/STRUCTURES"
+)
+
+// Stable categories reported in the "category" field of the error JSON.
+// They are the same on every engine, so callers can branch on them instead
+// of matching driver messages.
+const (
+    CategoryUniqueViolation      = "unique_violation"
+    CategoryForeignKeyViolation  = "foreign_key_violation"
+    CategoryNotNullViolation     = "not_null_violation"
+    CategoryCheckViolation       = "check_violation"
+    CategoryDeadlock             = "deadlock"
+    CategoryLockTimeout          = "lock_timeout"
+    CategorySerializationFailure = "serialization_failure"
+    CategorySyntaxError          = "syntax_error"
+    CategoryUndefinedObject      = "undefined_object"
+    CategoryPermissionDenied     = "permission_denied"
+    CategoryConnection           = "connection"
+    CategoryDataError            = "data_error"
+    CategoryTimeout              = "timeout"
+    CategoryCanceled             = "canceled"
+    CategoryOther                = "other"
+)
+
+// ErrorDetailer is implemented by dialects whose driver error type is not one
+// of mysql, pq, mssql, sqlite3 or godror. ErrorDetails fills Category,
+// SQLState, VendorCode, Constraint and Position; ok is false when err does not
+// come from the driver.
+type ErrorDetailer interface {
+    ErrorDetails(err error) (details STRC.ErrorResponse, ok bool)
+}
+
+var (
+    // "for key 'x'", "CONSTRAINT `x`", "constraint 'x'", "unique index 'x'"
+    quotedConstraint = regexp.MustCompile("(?i)(?:constraint|key|index)\\s+[`'\"]([^`'\"]+)[`'\"]")
+    // ORA-00001: unique constraint (SCHEMA.NAME) violated
+    oracleConstraint = regexp.MustCompile(`(?i)constraint \(([^)]+)\)`)
+    // UNIQUE constraint failed: table.column
+    sqliteConstraint = regexp.MustCompile(`(?i)constraint failed: (.+)$`)
+)
+
+// DescribeError returns the structured form of an error returned by the
+// transaction, cursor or stream functions of connector. A nil connector only
+// recognises the error types of the bundled drivers.
+func DescribeError(connector *Connector, err error) STRC.ErrorResponse {
+    var d Dialect = GenericDialect{}
+    if connector != nil {
+        d = connector.dialect
+    }
+    return describeError(context.Background(), d, err)
+}
+
+// describeError builds the ErrorResponse for err. A query ended by its context
+// is reported with ErrorCodeTimeout or ErrorCodeCanceled instead of the driver text.
+func describeError(ctx context.Context, d Dialect, err error) STRC.ErrorResponse {
+    switch {
+    case errors.Is(ctx.Err(), context.DeadlineExceeded), errors.Is(err, context.DeadlineExceeded):
+        return STRC.ErrorResponse{Error: "La consulta excedió el tiempo límite", Code: ErrorCodeTimeout, Category: CategoryTimeout}
+    case errors.Is(ctx.Err(), context.Canceled), errors.Is(err, context.Canceled):
+        return STRC.ErrorResponse{Error: "La consulta fue cancelada", Code: ErrorCodeCanceled, Category: CategoryCanceled}
+    }
+    var rejected *RejectedError
+    if errors.As(err, &rejected) {
+        return STRC.ErrorResponse{Error: err.Error(), Code: ErrorCodeRejected, Category: CategoryPermissionDenied}
+    }
+
+    var details STRC.ErrorResponse
+    var ok bool
+    if ed, isDetailer := d.(ErrorDetailer); isDetailer {
+        details, ok = ed.ErrorDetails(err)
+    }
+    if !ok {
+        details, ok = driverErrorDetails(err)
+    }
+
+    resp := STRC.ErrorResponse{Error: err.Error(), Category: CategoryOther}
+    if ok {
+        resp.SQLState = details.SQLState
+        resp.VendorCode = details.VendorCode
+        resp.Constraint = details.Constraint
+        resp.Position = details.Position
+        switch {
+        case details.Category != "":
+            resp.Category = details.Category
+        case details.SQLState != "":
+            resp.Category = categoryForSQLState(details.SQLState)
+        }
+    }
+    return resp
+}
+
+// driverErrorDetails reads the error types of the drivers bundled with the package
+func driverErrorDetails(err error) (STRC.ErrorResponse, bool) {
+    var myErr *mysql.MySQLError
+    if errors.As(err, &myErr) {
+        details := STRC.ErrorResponse{
+            VendorCode: int(myErr.Number),
+            Category:   mysqlCategory(myErr.Number),
+            Constraint: submatch(quotedConstraint, myErr.Message),
+        }
+        if myErr.SQLState != [5]byte{} {
+            details.SQLState = string(myErr.SQLState[:])
+        }
+        return details, true
+    }
+
+    var pqErr *pq.Error
+    if errors.As(err, &pqErr) {
+        details := STRC.ErrorResponse{
+            SQLState:   string(pqErr.Code),
+            Category:   categoryForSQLState(string(pqErr.Code)),
+            Constraint: pqErr.Constraint,
+        }
+        details.Position, _ = strconv.Atoi(pqErr.Position)
+        return details, true
+    }
+
+    var msErr mssql.Error
+    if errors.As(err, &msErr) {
+        return STRC.ErrorResponse{
+            VendorCode: int(msErr.Number),
+            Category:   sqlserverCategory(msErr.Number, msErr.Message),
+            Constraint: submatch(quotedConstraint, msErr.Message),
+        }, true
+    }
+
+    var liteErr sqlite3.Error
+    if errors.As(err, &liteErr) {
+        return STRC.ErrorResponse{
+            VendorCode: int(liteErr.ExtendedCode),
+            Category:   sqliteCategory(liteErr),
+            Constraint: submatch(sqliteConstraint, liteErr.Error()),
+        }, true
+    }
+
+    if oraErr, ok := godror.AsOraErr(err); ok {
+        details := STRC.ErrorResponse{
+            SQLState:   oraErr.SQLState(),
+            VendorCode: oraErr.Code(),
+            Category:   oracleCategory(oraErr.Code()),
+            Constraint: submatch(oracleConstraint, oraErr.Message()),
+        }
+        // OCI reports a 0-based offset, 0 when there is none
+        if oraErr.Offset() > 0 {
+            details.Position = oraErr.Offset() + 1
+        }
+        return details, true
+    }
+
+    var netErr net.Error
+    if errors.Is(err, driver.ErrBadConn) || errors.Is(err, mysql.ErrInvalidConn) || errors.As(err, &netErr) {
+        return STRC.ErrorResponse{Category: CategoryConnection}, true
+    }
+
+    return STRC.ErrorResponse{}, false
+}
+
+// categoryForSQLState maps a standard SQLSTATE to a category
+func categoryForSQLState(state string) string {
+    switch state {
+    case "23505":
+        return CategoryUniqueViolation
+    case "23503":
+        return CategoryForeignKeyViolation
+    case "23502":
+        return CategoryNotNullViolation
+    case "23514":
+        return CategoryCheckViolation
+    case "40P01":
+        return CategoryDeadlock
+    case "40001":
+        return CategorySerializationFailure
+    case "55P03":
+        return CategoryLockTimeout
+    case "57014":
+        return CategoryCanceled
+    case "42P01", "42703", "42704", "42883", "42S02", "42S22":
+        return CategoryUndefinedObject
+    case "42501":
+        return CategoryPermissionDenied
+    }
+    if len(state) < 2 {
+        return CategoryOther
+    }
+    switch state[:2] {
+    case "08":
+        return CategoryConnection
+    case "22":
+        return CategoryDataError
+    case "28":
+        return CategoryPermissionDenied
+    case "42":
+        return CategorySyntaxError
+    }
+    return CategoryOther
+}
+
+func mysqlCategory(number uint16) string {
+    switch number {
+    case 1062, 1586:
+        return CategoryUniqueViolation
+    case 1216, 1217, 1451, 1452:
+        return CategoryForeignKeyViolation
+    case 1048, 1364:
+        return CategoryNotNullViolation
+    case 3819:
+        return CategoryCheckViolation
+    case 1213:
+        return CategoryDeadlock
+    case 1205:
+        return CategoryLockTimeout
+    case 1064, 1149:
+        return CategorySyntaxError
+    case 1049, 1054, 1146, 1305:
+        return CategoryUndefinedObject
+    case 1044, 1045, 1142, 1143, 1227:
+        return CategoryPermissionDenied
+    case 1264, 1265, 1292, 1366, 1406, 1411:
+        return CategoryDataError
+    case 3024:
+        return CategoryTimeout
+    case 1317:
+        return CategoryCanceled
+    }
+    return ""
+}
+
+func sqlserverCategory(number int32, message string) string {
+    switch number {
+    case 2601, 2627:
+        return CategoryUniqueViolation
+    case 547:
+        // 547 is raised for both FOREIGN KEY and CHECK constraints
+        if strings.Contains(strings.ToUpper(message), "CHECK") {
+            return CategoryCheckViolation
+        }
+        return CategoryForeignKeyViolation
+    case 515:
+        return CategoryNotNullViolation
+    case 1205:
+        return CategoryDeadlock
+    case 1222:
+        return CategoryLockTimeout
+    case 3960:
+        return CategorySerializationFailure
+    case 102, 105, 156, 170:
+        return CategorySyntaxError
+    case 207, 208, 2812, 4121:
+        return CategoryUndefinedObject
+    case 229, 230, 262, 18456:
+        return CategoryPermissionDenied
+    case 220, 241, 242, 245, 2628, 8114, 8115, 8152:
+        return CategoryDataError
+    }
+    return ""
+}
+
+func sqliteCategory(err sqlite3.Error) string {
+    switch err.ExtendedCode {
+    case sqlite3.ErrConstraintUnique, sqlite3.ErrConstraintPrimaryKey, sqlite3.ErrConstraintRowID:
+        return CategoryUniqueViolation
+    case sqlite3.ErrConstraintForeignKey:
+        return CategoryForeignKeyViolation
+    case sqlite3.ErrConstraintNotNull:
+        return CategoryNotNullViolation
+    case sqlite3.ErrConstraintCheck:
+        return CategoryCheckViolation
+    }
+    switch err.Code {
+    case sqlite3.ErrBusy, sqlite3.ErrLocked:
+        return CategoryLockTimeout
+    case sqlite3.ErrPerm, sqlite3.ErrAuth, sqlite3.ErrReadonly:
+        return CategoryPermissionDenied
+    case sqlite3.ErrCantOpen:
+        return CategoryConnection
+    case sqlite3.ErrTooBig, sqlite3.ErrMismatch, sqlite3.ErrRange:
+        return CategoryDataError
+    case sqlite3.ErrInterrupt:
+        return CategoryCanceled
+    case sqlite3.ErrError:
+        // SQLite reports parse and name resolution errors with the generic code
+        message := err.Error()
+        switch {
+        case strings.Contains(message, "syntax error"), strings.Contains(message, "incomplete input"):
+            return CategorySyntaxError
+        case strings.Contains(message, "no such "):
+            return CategoryUndefinedObject
+        }
+    }
+    return ""
+}
+
+func oracleCategory(code int) string {
+    switch code {
+    case 1:
+        return CategoryUniqueViolation
+    case 2291, 2292:
+        return CategoryForeignKeyViolation
+    case 1400, 1407:
+        return CategoryNotNullViolation
+    case 2290:
+        return CategoryCheckViolation
+    case 60:
+        return CategoryDeadlock
+    case 8177:
+        return CategorySerializationFailure
+    case 54, 30006:
+        return CategoryLockTimeout
+    case 904, 942, 4043:
+        return CategoryUndefinedObject
+    case 1017, 1031:
+        return CategoryPermissionDenied
+    case 1013:
+        return CategoryCanceled
+    case 3113, 3114, 3135, 12154, 12170, 12514, 12541:
+        return CategoryConnection
+    case 1438, 1722, 1840, 1843, 1858, 1861, 6502, 12899:
+        return CategoryDataError
+    }
+    if code >= 900 && code < 1000 {
+        return CategorySyntaxError
+    }
+    return ""
+}
+
+func submatch(re *regexp.Regexp, s string) string {
+    if m := re.FindStringSubmatch(s); m != nil {
+        return m[1]
+    }
+    return ""
+}
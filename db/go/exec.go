@@ -0,0 +1,329 @@
+package db
+
+import (
+    "bytes"
+    "context"
+    "database/sql"
+    "encoding/json"
+    "fmt"
+    "strings"
+    "sync"
+    "time"
+
+    ENCODER "github.com/IngenieroRicardo/db/ENCODER"
+    STRC "github.com/IngenieroRicardo/db/STRUCTURES"
+)
+
+// ReturningDialect is implemented by dialects that cannot read a RETURNING
+// clause as a result set and have to bind its columns as out parameters
+// (Oracle RETURNING ... INTO). Engines that return the rows of RETURNING or
+// OUTPUT as a normal result set do not need it.
+type ReturningDialect interface {
+    // RewriteReturning adds the out binds for the RETURNING clause of query,
+    // numbered after the argCount arguments given by the caller, and returns
+    // the returned column names. ok is false when query has no such clause.
+    RewriteReturning(query string, argCount int) (rewritten string, columns []string, ok bool)
+}
+
+// isWriteStatement reports whether query runs through Exec: a statement the
+// dialect considers non-returning that is not a procedure call, since
+// procedures may still produce result sets
+func isWriteStatement(d Dialect, query string) bool {
+    if !d.IsNonReturning(query) {
+        return false
+    }
+    for _, st := range ClassifyStatements(d, query) {
+        if st.Kind == StatementCall {
+            return false
+        }
+    }
+    return true
+}
+
+// runExec executes a write statement and reports rows_affected, last_insert_id
+// and the elapsed time. Statements with a RETURNING or OUTPUT clause keep
+// going through Query so their rows are returned.
+func runExec(ctx context.Context, q execer, d Dialect, mode ENCODER.Mode, query string, args ...any) STRC.InternalResult {
+    if rd, ok := d.(ReturningDialect); ok {
+        if rewritten, columns, ok := rd.RewriteReturning(query, len(args)); ok {
+            return runExecReturning(ctx, q, d, mode, query, rewritten, columns, args...)
+        }
+        if hasReturningClause(d, query) {
+            // El motor no devuelve RETURNING como filas: Query fallaría
+            return STRC.InternalResult{
+                Json:     createErrorJSON("RETURNING solo se admite en una sentencia con una lista de columnas, seguida o no de INTO con un destino :nombre por columna"),
+                Is_error: 1,
+                Is_empty: 0,
+            }
+        }
+        if ClassifyStatement(d, query) == StatementDDL {
+            forgetReturningTypes()
+        }
+    }
+    if hasReturningClause(d, query) {
+        rows, err := q.QueryContext(ctx, query, args...)
+        if err != nil {
+            return errorResult(ctx, d, "Error en la consulta SQL", err)
+        }
+        defer rows.Close()
+        return buildResult(ctx, rows, d, mode, query)
+    }
+
+    start := time.Now()
+    res, err := q.ExecContext(ctx, query, args...)
+    if err != nil {
+        return errorResult(ctx, d, "Error en la consulta SQL", err)
+    }
+    elapsed := time.Since(start)
+
+    resp := STRC.SuccessResponse{Status: "OK", ElapsedMs: elapsedMs(elapsed)}
+    // DDL leaves the driver counters untouched, so only DML reports them
+    switch keyword := statementVerb(d, query); keyword {
+    case "INSERT", "REPLACE", "UPDATE", "DELETE", "MERGE", "UPSERT":
+        if rowsAffected, err := res.RowsAffected(); err == nil {
+            resp.RowsAffected = &rowsAffected
+        }
+        if keyword == "INSERT" || keyword == "REPLACE" || keyword == "UPSERT" {
+            // PostgreSQL, SQL Server and Oracle report an error here: use RETURNING/OUTPUT
+            if lastInsertId, err := res.LastInsertId(); err == nil {
+                resp.LastInsertId = lastInsertId
+            }
+        }
+    }
+
+    jsonData, _ := json.Marshal(resp)
+    result := STRC.InternalResult{
+        Json:     string(jsonData),
+        Is_error: 0,
+        Is_empty: 1,
+    }
+    if resp.RowsAffected != nil {
+        result.Rows = *resp.RowsAffected
+    }
+    return result
+}
+
+// hasReturningClause reports whether a statement of query has a RETURNING
+// clause (PostgreSQL, SQLite, MariaDB, Oracle) or OUTPUT INSERTED/DELETED (SQL
+// Server), leaving out the words inside literals, identifiers and comments
+func hasReturningClause(d Dialect, query string) bool {
+    for _, tokens := range lexStatements(d, query).statements {
+        for i, t := range tokens {
+            if !t.word {
+                continue
+            }
+            if t.text == "RETURNING" {
+                return true
+            }
+            if t.text == "OUTPUT" && i+1 < len(tokens) && (tokens[i+1].text == "INSERTED" || tokens[i+1].text == "DELETED") {
+                return true
+            }
+        }
+    }
+    return false
+}
+
+// runExecReturning executes a statement rewritten with out binds and returns
+// the bound values as a single row. The returned columns are described by
+// selecting them from the table of the statement, so they are encoded like
+// the columns of a query in the mode of the connector.
+func runExecReturning(ctx context.Context, q execer, d Dialect, mode ENCODER.Mode, query string, rewritten string, columns []string, args ...any) STRC.InternalResult {
+    colTypes := returningTypes(ctx, q, d, query, columns)
+
+    values := make([]any, len(columns))
+    allArgs := append([]any{}, args...)
+    for i := range values {
+        switch {
+        case colTypes != nil && d.IsBinaryColumn(strings.ToUpper(colTypes[i].DatabaseTypeName())):
+            values[i] = new([]byte)
+        case colTypes != nil && ENCODER.Kind(colTypes[i]) == ENCODER.KindTime:
+            values[i] = new(sql.NullTime)
+        default:
+            // El driver devuelve NULL como cadena vacía, que en Oracle también es NULL
+            values[i] = new(string)
+        }
+        allArgs = append(allArgs, sql.Out{Dest: values[i]})
+    }
+
+    if _, err := q.ExecContext(ctx, rewritten, allArgs...); err != nil {
+        return errorResult(ctx, d, "Error en la consulta SQL", err)
+    }
+
+    var buf bytes.Buffer
+    buf.WriteString("[{")
+    for i, column := range columns {
+        if i > 0 {
+            buf.WriteString(",")
+        }
+        ENCODER.WriteString(&buf, column)
+        buf.WriteString(":")
+
+        var rb []byte
+        switch v := values[i].(type) {
+        case *[]byte:
+            rb = *v
+        case *sql.NullTime:
+            if v.Valid {
+                // El mismo texto que database/sql da a un DATE leído como RawBytes
+                rb = []byte(v.Time.Format(time.RFC3339Nano))
+            }
+        case *string:
+            if *v != "" {
+                rb = []byte(*v)
+            }
+        }
+        switch {
+        case rb == nil:
+            buf.WriteString("null")
+        case colTypes == nil:
+            ENCODER.WriteString(&buf, string(rb))
+        default:
+            ENCODER.WriteValue(&buf, mode, colTypes[i], rb, d.IsBinaryColumn)
+        }
+    }
+    buf.WriteString("}]")
+
+    return STRC.InternalResult{
+        Json:     buf.String(),
+        Is_error: 0,
+        Is_empty: 0,
+        Rows:     1,
+    }
+}
+
+// returningKey identifies the columns of a RETURNING clause in returningCache
+type returningKey struct {
+    table   string
+    columns string
+}
+
+// returningCache keeps the column types found by returningTypes, so the
+// describing query runs once per table and column list. Any DDL run through
+// a ReturningDialect drops it, since it may change a column type.
+var returningCache = struct {
+    sync.RWMutex
+    types map[returningKey][]*sql.ColumnType
+}{
+    types: make(map[returningKey][]*sql.ColumnType),
+}
+
+// forgetReturningTypes empties returningCache
+func forgetReturningTypes() {
+    returningCache.Lock()
+    defer returningCache.Unlock()
+    clear(returningCache.types)
+}
+
+// returningTypes describes the columns of a RETURNING clause by selecting the
+// same expressions from the table of query with a condition no row meets. It
+// returns nil when they cannot be described that way.
+func returningTypes(ctx context.Context, q execer, d Dialect, query string, columns []string) []*sql.ColumnType {
+    statements := ClassifyStatements(d, query)
+    if len(statements) != 1 || len(statements[0].Tables) == 0 {
+        return nil
+    }
+    key := returningKey{table: statements[0].Tables[0], columns: strings.Join(columns, ", ")}
+
+    returningCache.RLock()
+    colTypes, ok := returningCache.types[key]
+    returningCache.RUnlock()
+    if ok {
+        return colTypes
+    }
+
+    rows, err := q.QueryContext(ctx, fmt.Sprintf("SELECT %s FROM %s WHERE 1 = 0", key.columns, key.table))
+    if err != nil {
+        return nil
+    }
+    defer rows.Close()
+
+    colTypes, err = rows.ColumnTypes()
+    if err != nil || len(colTypes) != len(columns) {
+        return nil
+    }
+
+    returningCache.Lock()
+    returningCache.types[key] = colTypes
+    returningCache.Unlock()
+    return colTypes
+}
+
+// RewriteReturning turns "... RETURNING a, b" into "... RETURNING a, b INTO :n, :m".
+// INTO targets the statement already names, one :name per column, are
+// replaced the same way, since the values are returned as a row. PL/SQL
+// blocks and batches are left to the caller.
+func (o oracleDialect) RewriteReturning(query string, argCount int) (string, []string, bool) {
+    lexed := lexStatements(o, query)
+    if lexed.unterminated || len(lexed.statements) != 1 {
+        return "", nil, false
+    }
+    tokens := lexed.statements[0]
+    if classifyStatement(tokens).Kind != StatementWrite {
+        return "", nil, false
+    }
+
+    at := -1
+    for i, t := range tokens {
+        if t.word && t.depth == 0 && t.text == "RETURNING" {
+            at = i
+            break
+        }
+    }
+    if at < 0 || at == len(tokens)-1 {
+        return "", nil, false
+    }
+
+    end := len(tokens)
+    for i := at + 1; i < len(tokens); i++ {
+        if tokens[i].word && tokens[i].depth == 0 && tokens[i].text == "INTO" {
+            end = i
+            break
+        }
+    }
+
+    var columns, marks []string
+    first := at + 1
+    for i := at + 1; i <= end; i++ {
+        if i < end && (tokens[i].depth > 0 || tokens[i].text != ",") {
+            continue
+        }
+        if i == first {
+            return "", nil, false
+        }
+        columns = append(columns, query[tokens[first].pos:tokens[i-1].end])
+        marks = append(marks, o.Placeholder(argCount+len(marks)+1))
+        first = i + 1
+    }
+    if end < len(tokens) && !intoBinds(tokens[end+1:], len(columns)) {
+        return "", nil, false
+    }
+    return fmt.Sprintf("%s INTO %s", query[:tokens[end-1].end], strings.Join(marks, ", ")), columns, true
+}
+
+// intoBinds reports whether tokens are n binds separated by commas, as in :a, :b
+func intoBinds(tokens []sqlToken, n int) bool {
+    if len(tokens) != 3*n-1 {
+        return false
+    }
+    for i, t := range tokens {
+        switch i % 3 {
+        case 0:
+            if t.text != ":" {
+                return false
+            }
+        case 1:
+            if !t.word {
+                return false
+            }
+        case 2:
+            if t.text != "," {
+                return false
+            }
+        }
+    }
+    return true
+}
+
+func elapsedMs(d time.Duration) float64 {
+    return float64(d.Microseconds()) / 1000
+}
@@ -0,0 +1,245 @@
+package db
+
+import (
+    "bufio"
+    "bytes"
+    "context"
+    "database/sql"
+    "fmt"
+    "io"
+    "strings"
+    "unicode/utf8"
+
+    ARGS "github.com/IngenieroRicardo/db/ARGS"
+    ENCODER "github.com/IngenieroRicardo/db/ENCODER"
+    FILE "github.com/WebPrivada/SDK/file/go"
+)
+
+// ExportFormat is the file format written by Export
+type ExportFormat string
+
+const (
+    ExportCSV    ExportFormat = "csv"
+    ExportTSV    ExportFormat = "tsv"
+    ExportNDJSON ExportFormat = "ndjson"
+)
+
+// ExportOptions describes the file written by Export. The zero value writes
+// CSV with a header record, separated by commas and quoted where needed.
+//
+// In CSV, NULL is an empty field; with QuoteAll every other value is quoted,
+// so NULL and the empty string differ. TSV escapes tabs, line breaks and
+// backslashes with a backslash and writes NULL as \N, the way LOAD DATA and
+// COPY read it. NDJSON rows are the objects returned by SQLrunonLoad. Binary
+// columns are base64-encoded in every format, as in the JSON results.
+type ExportOptions struct {
+    Format    ExportFormat
+    Delimiter rune // CSV field separator, ',' when zero
+    QuoteAll  bool // CSV: quote every value that is not NULL
+    NoHeader  bool // CSV and TSV: leave out the record of column names
+    BOM       bool // CSV and TSV: start with the UTF-8 byte order mark Excel needs to read UTF-8
+}
+
+// utf8BOM marks the file as UTF-8 for Excel
+const utf8BOM = "\xEF\xBB\xBF"
+
+// Export runs the query and streams its rows to w in the format of opts,
+// without holding the result set in memory. Each result set of a CSV or TSV
+// export starts with its own header. It returns the number of rows written.
+func Export(connector *Connector, w io.Writer, opts ExportOptions, query string, args ...string) (int64, error) {
+    return ExportContext(context.Background(), connector, w, opts, query, args...)
+}
+
+// ExportContext is Export bounded by ctx
+func ExportContext(ctx context.Context, connector *Connector, w io.Writer, opts ExportOptions, query string, args ...string) (int64, error) {
+    opts, err := exportOptions(opts)
+    if err != nil {
+        return 0, err
+    }
+    goArgs, err := ARGS.Parse(args)
+    if err != nil {
+        return 0, err
+    }
+
+    return observeRows(ctx, connector, query, goArgs, func() (int64, error) {
+        cursor, err := openCursor(ctx, connector, query, goArgs)
+        if err != nil {
+            return 0, err
+        }
+        defer CloseCursor(cursor)
+
+        return exportRows(cursor, w, opts)
+    })
+}
+
+// ExportFile is Export writing to the file at path, which is created once
+// the query has run, along with its directory, and replaced if it exists
+func ExportFile(connector *Connector, path string, opts ExportOptions, query string, args ...string) (int64, error) {
+    opts, err := exportOptions(opts)
+    if err != nil {
+        return 0, err
+    }
+    goArgs, err := ARGS.Parse(args)
+    if err != nil {
+        return 0, err
+    }
+
+    ctx := context.Background()
+    return observeRows(ctx, connector, query, goArgs, func() (int64, error) {
+        return exportFile(ctx, connector, path, opts, query, goArgs)
+    })
+}
+
+// exportFile opens the cursor before creating the file, so a failed query
+// leaves no file behind
+func exportFile(ctx context.Context, connector *Connector, path string, opts ExportOptions, query string, goArgs []any) (int64, error) {
+    cursor, err := openCursor(ctx, connector, query, goArgs)
+    if err != nil {
+        return 0, err
+    }
+    defer CloseCursor(cursor)
+
+    f, err := FILE.CreateFile(path)
+    if err != nil {
+        return 0, fmt.Errorf("error al crear el archivo: %w", err)
+    }
+
+    w := bufio.NewWriterSize(f, 64*1024)
+    total, err := exportRows(cursor, w, opts)
+    if err == nil {
+        err = w.Flush()
+    }
+    if cerr := f.Close(); err == nil && cerr != nil {
+        err = fmt.Errorf("error al cerrar el archivo: %w", cerr)
+    }
+    return total, err
+}
+
+// exportOptions fills in the defaults of opts and rejects the invalid ones
+func exportOptions(opts ExportOptions) (ExportOptions, error) {
+    if opts.Format == "" {
+        opts.Format = ExportCSV
+    }
+    switch opts.Format {
+    case ExportCSV:
+        if opts.Delimiter == 0 {
+            opts.Delimiter = ','
+        }
+        if opts.Delimiter == '"' || opts.Delimiter == '\r' || opts.Delimiter == '\n' || !utf8.ValidRune(opts.Delimiter) || opts.Delimiter == utf8.RuneError {
+            return opts, fmt.Errorf("delimitador no válido: %q", opts.Delimiter)
+        }
+    case ExportTSV:
+        opts.Delimiter = '\t'
+    case ExportNDJSON:
+    default:
+        return opts, fmt.Errorf("formato de exportación no válido: %q", opts.Format)
+    }
+    return opts, nil
+}
+
+// exportRows writes the remaining rows of cursor to w, one Write call per row
+func exportRows(cursor *Cursor, w io.Writer, opts ExportOptions) (int64, error) {
+    if opts.Format == ExportNDJSON {
+        return streamNDJSON(cursor, w)
+    }
+
+    if opts.BOM {
+        if _, err := io.WriteString(w, utf8BOM); err != nil {
+            return 0, fmt.Errorf("error al escribir el archivo: %w", err)
+        }
+    }
+
+    var total int64
+    var line bytes.Buffer
+    header := !opts.NoHeader
+    for {
+        if header {
+            line.Reset()
+            for i, column := range cursor.writer.columns {
+                writeField(&line, opts, i, column)
+            }
+            line.WriteByte('\n')
+            if _, err := w.Write(line.Bytes()); err != nil {
+                return total, fmt.Errorf("error al escribir la cabecera: %w", err)
+            }
+            header = false
+        }
+
+        if !cursor.rows.Next() {
+            if err := cursor.rows.Err(); err != nil {
+                return total, fmt.Errorf("Error después de iterar filas: %w", err)
+            }
+            if !cursor.rows.NextResultSet() {
+                return total, nil
+            }
+            rw, err := newRowWriter(cursor.rows, cursor.writer.dialect, cursor.writer.mode)
+            if err != nil {
+                return total, err
+            }
+            cursor.writer = rw
+            header = !opts.NoHeader
+            continue
+        }
+
+        if err := cursor.rows.Scan(cursor.writer.values...); err != nil {
+            return total, fmt.Errorf("Error al escanear fila: %w", err)
+        }
+
+        line.Reset()
+        for i, value := range cursor.writer.values {
+            rb := *(value.(*sql.RawBytes))
+            if rb == nil {
+                writeNull(&line, opts, i)
+                continue
+            }
+            writeField(&line, opts, i, ENCODER.TextValue(cursor.writer.colTypes[i], rb, cursor.writer.dialect.IsBinaryColumn))
+        }
+        line.WriteByte('\n')
+        if _, err := w.Write(line.Bytes()); err != nil {
+            return total, fmt.Errorf("error al escribir fila %d: %w", total+1, err)
+        }
+        total++
+    }
+}
+
+// tsvEscaper escapes the characters a TSV field cannot hold
+var tsvEscaper = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
+
+// writeField writes field i of a CSV or TSV record
+func writeField(buf *bytes.Buffer, opts ExportOptions, i int, field string) {
+    if i > 0 {
+        buf.WriteRune(opts.Delimiter)
+    }
+    if opts.Format == ExportTSV {
+        tsvEscaper.WriteString(buf, field)
+        return
+    }
+    if !opts.QuoteAll && !fieldNeedsQuotes(field, opts.Delimiter) {
+        buf.WriteString(field)
+        return
+    }
+    buf.WriteByte('"')
+    buf.WriteString(strings.ReplaceAll(field, `"`, `""`))
+    buf.WriteByte('"')
+}
+
+func writeNull(buf *bytes.Buffer, opts ExportOptions, i int) {
+    if i > 0 {
+        buf.WriteRune(opts.Delimiter)
+    }
+    if opts.Format == ExportTSV {
+        buf.WriteString(`\N`)
+    }
+}
+
+// fieldNeedsQuotes follows encoding/csv: fields holding the delimiter, a
+// quote or a line break, or starting with a blank, are quoted
+func fieldNeedsQuotes(field string, delimiter rune) bool {
+    if field == "" {
+        return false
+    }
+    if strings.ContainsRune(field, delimiter) || strings.ContainsAny(field, "\"\r\n") {
+        return true
+    }
+    return field[0] == ' ' || field[0] == '\t'
+}
@@ -0,0 +1,229 @@
+package db
+
+import (
+    "context"
+    "database/sql"
+    "encoding/json"
+    "fmt"
+    "sort"
+    "sync"
+    "time"
+
+    STRC "github.com/IngenieroRicardo/db/STRUCTURES"
+)
+
+// latencySamples is how many of the most recent statement latencies a
+// connector keeps to compute its percentiles
+const latencySamples = 1024
+
+// queryStats counts the statements run on a connector
+type queryStats struct {
+    mu        sync.Mutex
+    queries   int64
+    errors    int64
+    latencies [latencySamples]time.Duration
+    next      int
+    filled    bool
+}
+
+func newQueryStats() *queryStats {
+    return &queryStats{}
+}
+
+func (s *queryStats) record(d time.Duration, failed bool) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.queries++
+    if failed {
+        s.errors++
+    }
+    s.latencies[s.next] = d
+    s.next++
+    if s.next == latencySamples {
+        s.next = 0
+        s.filled = true
+    }
+}
+
+// snapshot returns the counters and the p50, p90 and p99 latencies in milliseconds
+func (s *queryStats) snapshot() (queries, errors int64, p50, p90, p99 float64) {
+    s.mu.Lock()
+    n := s.next
+    if s.filled {
+        n = latencySamples
+    }
+    samples := make([]time.Duration, n)
+    copy(samples, s.latencies[:n])
+    queries, errors = s.queries, s.errors
+    s.mu.Unlock()
+
+    if n == 0 {
+        return queries, errors, 0, 0, 0
+    }
+    sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+    percentile := func(p int) float64 {
+        return elapsedMs(samples[(n-1)*p/100])
+    }
+    return queries, errors, percentile(50), percentile(90), percentile(99)
+}
+
+// handle returns the *sql.DB of the connector, reopening it first when the
+// health checker dropped it
+func (c *Connector) handle() (*sql.DB, error) {
+    c.mu.RLock()
+    if !c.dead || c.closed {
+        db := c.db
+        c.mu.RUnlock()
+        return db, nil
+    }
+    c.mu.RUnlock()
+
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    if c.dead && !c.closed {
+        db, err := openConnection(c.dialect, c.conexion, c.session()...)
+        if err != nil {
+            return nil, fmt.Errorf("Error al reconectar a la base de datos: %w", err)
+        }
+        c.settings.apply(db)
+        c.db = db
+        c.dead = false
+        c.reconnects++
+    }
+    return c.db, nil
+}
+
+// drop closes db if it is still the connector handle so the next use reopens it
+func (c *Connector) drop(db *sql.DB) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    if c.db != db || c.dead || c.closed {
+        return
+    }
+    c.dead = true
+    c.stmts.clear()
+    db.Close()
+}
+
+// check pings the connector when none of its connections is in use. A
+// dropped connector is reopened so replicas come back into rotation.
+func (c *Connector) check(timeout time.Duration) {
+    c.mu.RLock()
+    db, dead, closed := c.db, c.dead, c.closed
+    c.mu.RUnlock()
+    if closed {
+        return
+    }
+    if dead {
+        c.handle()
+        return
+    }
+    if db.Stats().InUse > 0 {
+        return
+    }
+
+    ctx, cancel := context.WithTimeout(context.Background(), timeout)
+    defer cancel()
+    if err := db.PingContext(ctx); err != nil {
+        c.drop(db)
+    }
+}
+
+// healthChecker is the background goroutine started by StartHealthCheck
+var healthChecker struct {
+    sync.Mutex
+    stop chan struct{}
+    done chan struct{}
+}
+
+// StartHealthCheck pings every idle pooled connector each interval. A connector
+// that does not answer is closed and transparently reopened on its next use or
+// on the next round of the checker.
+// Calling it again restarts the checker with the new interval.
+func StartHealthCheck(interval time.Duration) {
+    StopHealthCheck()
+    if interval <= 0 {
+        return
+    }
+
+    healthChecker.Lock()
+    defer healthChecker.Unlock()
+    stop, done := make(chan struct{}), make(chan struct{})
+    healthChecker.stop, healthChecker.done = stop, done
+
+    go func() {
+        defer close(done)
+        ticker := time.NewTicker(interval)
+        defer ticker.Stop()
+        for {
+            select {
+            case <-stop:
+                return
+            case <-ticker.C:
+                checkConnectors(interval)
+            }
+        }
+    }()
+}
+
+// StopHealthCheck stops the checker started by StartHealthCheck and waits for it
+func StopHealthCheck() {
+    healthChecker.Lock()
+    defer healthChecker.Unlock()
+    if healthChecker.stop == nil {
+        return
+    }
+    close(healthChecker.stop)
+    <-healthChecker.done
+    healthChecker.stop, healthChecker.done = nil, nil
+}
+
+func checkConnectors(timeout time.Duration) {
+    connectionPool.RLock()
+    connectors := make([]*Connector, 0, len(connectionPool.connections))
+    for _, conn := range connectionPool.connections {
+        connectors = append(connectors, conn)
+    }
+    connectionPool.RUnlock()
+
+    for _, conn := range connectors {
+        conn.check(timeout)
+    }
+}
+
+// PoolStats reports the sql.DBStats of the connector together with the number
+// of statements run through SQLrunonLoad, how many failed and their latency
+// percentiles over the last samples
+func PoolStats(connector *Connector) STRC.InternalResult {
+    connector.mu.RLock()
+    dbStats := connector.db.Stats()
+    healthy := !connector.dead && !connector.closed
+    reconnects := connector.reconnects
+    connector.mu.RUnlock()
+
+    queries, errors, p50, p90, p99 := connector.stats.snapshot()
+
+    jsonData, _ := json.Marshal(STRC.PoolStats{
+        MaxOpenConnections: dbStats.MaxOpenConnections,
+        OpenConnections:    dbStats.OpenConnections,
+        InUse:              dbStats.InUse,
+        Idle:               dbStats.Idle,
+        WaitCount:          dbStats.WaitCount,
+        WaitDurationMs:     elapsedMs(dbStats.WaitDuration),
+        MaxIdleClosed:      dbStats.MaxIdleClosed,
+        MaxIdleTimeClosed:  dbStats.MaxIdleTimeClosed,
+        MaxLifetimeClosed:  dbStats.MaxLifetimeClosed,
+        Queries:            queries,
+        Errors:             errors,
+        LatencyP50Ms:       p50,
+        LatencyP90Ms:       p90,
+        LatencyP99Ms:       p99,
+        Healthy:            healthy,
+        Reconnects:         reconnects,
+    })
+    return STRC.InternalResult{
+        Json:     string(jsonData),
+        Is_error: 0,
+        Is_empty: 0,
+    }
+}
@@ -0,0 +1,230 @@
+package db
+
+import (
+    "context"
+    "database/sql"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "log/slog"
+    "sync"
+    "time"
+
+    STRC "github.com/IngenieroRicardo/db/STRUCTURES"
+)
+
+// QueryEvent describes a statement to the hooks. Args holds the type of each
+// argument, never its value. Duration, Rows, Code and Err are set once the
+// statement has run.
+type QueryEvent struct {
+    Driver   string
+    Query    string
+    Args     []string
+    Duration time.Duration
+    Rows     int64  // rows returned, or affected by a write
+    Code     string // the error code of the JSON error, see ErrorCodeTimeout
+    Err      error
+}
+
+// Hook observes the statements run through SQLrun, SQLrunonLoad, SQLrunOnTx
+// and the other connector entry points: procedure calls, bulk loads, copies,
+// exports, streams and cursors. A cursor is observed until it is open, an
+// export or a stream until its last row is written and a copy until its last
+// chunk. Both callbacks run on the goroutine of the statement, so they should
+// return quickly. Hooks are compared with == when removed.
+type Hook interface {
+    BeforeQuery(ctx context.Context, event *QueryEvent)
+    AfterQuery(ctx context.Context, event *QueryEvent)
+}
+
+// globalHooks observe the one-shot SQLrun path and every connector
+var globalHooks struct {
+    sync.RWMutex
+    hooks []Hook
+}
+
+// AddGlobalHook registers a hook for the one-shot SQLrun path and every connector
+func AddGlobalHook(hook Hook) {
+    globalHooks.Lock()
+    defer globalHooks.Unlock()
+    globalHooks.hooks = append(globalHooks.hooks, hook)
+}
+
+// RemoveGlobalHook unregisters a hook added with AddGlobalHook
+func RemoveGlobalHook(hook Hook) {
+    globalHooks.Lock()
+    defer globalHooks.Unlock()
+    globalHooks.hooks = withoutHook(globalHooks.hooks, hook)
+}
+
+// AddHook registers a hook for the statements run on the connector, its
+// replicas and its transactions
+func AddHook(connector *Connector, hook Hook) {
+    connector.mu.Lock()
+    defer connector.mu.Unlock()
+    connector.hooks = append(connector.hooks, hook)
+}
+
+// RemoveHook unregisters a hook added with AddHook
+func RemoveHook(connector *Connector, hook Hook) {
+    connector.mu.Lock()
+    defer connector.mu.Unlock()
+    connector.hooks = withoutHook(connector.hooks, hook)
+}
+
+// withoutHook returns a new slice so callers holding the old one are unaffected
+func withoutHook(hooks []Hook, hook Hook) []Hook {
+    kept := make([]Hook, 0, len(hooks))
+    for _, h := range hooks {
+        if h != hook {
+            kept = append(kept, h)
+        }
+    }
+    return kept
+}
+
+// hooksFor returns the global hooks followed by those of connector, which may be nil
+func hooksFor(connector *Connector) []Hook {
+    globalHooks.RLock()
+    hooks := globalHooks.hooks
+    globalHooks.RUnlock()
+    if connector == nil {
+        return hooks
+    }
+
+    connector.mu.RLock()
+    defer connector.mu.RUnlock()
+    if len(connector.hooks) == 0 {
+        return hooks
+    }
+    return append(append([]Hook(nil), hooks...), connector.hooks...)
+}
+
+// observe calls run between the BeforeQuery and AfterQuery callbacks of hooks
+func observe(ctx context.Context, hooks []Hook, driver string, query string, args []any, run func() STRC.InternalResult) STRC.InternalResult {
+    if len(hooks) == 0 {
+        return run()
+    }
+
+    event := &QueryEvent{Driver: driver, Query: query, Args: redactArgs(args)}
+    for _, hook := range hooks {
+        hook.BeforeQuery(ctx, event)
+    }
+
+    start := time.Now()
+    result := run()
+    event.Duration = time.Since(start)
+    event.Rows = result.Rows
+    if result.Is_error != 0 {
+        event.Code, event.Err = resultError(result)
+    }
+
+    for _, hook := range hooks {
+        hook.AfterQuery(ctx, event)
+    }
+    return result
+}
+
+// observeRows is observe for the connector entry points that report rows
+// and a Go error instead of a result
+func observeRows(ctx context.Context, connector *Connector, query string, args []any, run func() (int64, error)) (int64, error) {
+    var rows int64
+    var err error
+    observe(ctx, hooksFor(connector), connector.driver, query, args, func() STRC.InternalResult {
+        rows, err = run()
+        if err != nil {
+            result := errorResult(ctx, connector.dialect, "", err)
+            result.Rows = rows
+            return result
+        }
+        return STRC.InternalResult{Rows: rows}
+    })
+    return rows, err
+}
+
+// redactArgs keeps the type of every argument and the length of text and binary values
+func redactArgs(args []any) []string {
+    redacted := make([]string, len(args))
+    for i, arg := range args {
+        switch v := arg.(type) {
+        case nil:
+            redacted[i] = "null"
+        case string:
+            redacted[i] = fmt.Sprintf("string(%d)", len(v))
+        case []byte:
+            redacted[i] = fmt.Sprintf("blob(%d)", len(v))
+        case sql.NamedArg:
+            redacted[i] = "@" + v.Name + "=" + redactArgs([]any{v.Value})[0]
+        default:
+            redacted[i] = fmt.Sprintf("%T", v)
+        }
+    }
+    return redacted
+}
+
+// resultError reads the error code and message back from the JSON of a failed result
+func resultError(result STRC.InternalResult) (string, error) {
+    var resp STRC.ErrorResponse
+    json.Unmarshal([]byte(result.Json), &resp)
+    return resp.Code, errors.New(resp.Error)
+}
+
+// slogHook logs every statement once it has run
+type slogHook struct {
+    logger *slog.Logger
+}
+
+// NewSlogHook returns a hook logging every statement on logger: at Info level
+// when it succeeds and at Error level when it fails
+func NewSlogHook(logger *slog.Logger) Hook {
+    return &slogHook{logger: logger}
+}
+
+func (h *slogHook) BeforeQuery(ctx context.Context, event *QueryEvent) {}
+
+func (h *slogHook) AfterQuery(ctx context.Context, event *QueryEvent) {
+    if event.Err != nil {
+        h.logger.LogAttrs(ctx, slog.LevelError, "consulta fallida", eventAttrs(event)...)
+        return
+    }
+    h.logger.LogAttrs(ctx, slog.LevelInfo, "consulta", eventAttrs(event)...)
+}
+
+// slowQueryHook logs the statements that take threshold or longer
+type slowQueryHook struct {
+    logger    *slog.Logger
+    threshold time.Duration
+}
+
+// NewSlowQueryHook returns a hook logging at Warn level the statements that
+// take threshold or longer, failed or not
+func NewSlowQueryHook(logger *slog.Logger, threshold time.Duration) Hook {
+    return &slowQueryHook{logger: logger, threshold: threshold}
+}
+
+func (h *slowQueryHook) BeforeQuery(ctx context.Context, event *QueryEvent) {}
+
+func (h *slowQueryHook) AfterQuery(ctx context.Context, event *QueryEvent) {
+    if event.Duration < h.threshold {
+        return
+    }
+    attrs := append(eventAttrs(event), slog.Float64("threshold_ms", elapsedMs(h.threshold)))
+    h.logger.LogAttrs(ctx, slog.LevelWarn, "consulta lenta", attrs...)
+}
+
+func eventAttrs(event *QueryEvent) []slog.Attr {
+    attrs := []slog.Attr{
+        slog.String("driver", event.Driver),
+        slog.String("query", event.Query),
+        slog.Any("args", event.Args),
+        slog.Float64("duration_ms", elapsedMs(event.Duration)),
+        slog.Int64("rows", event.Rows),
+    }
+    if event.Err != nil {
+        attrs = append(attrs, slog.String("error", event.Err.Error()))
+    }
+    if event.Code != "" {
+        attrs = append(attrs, slog.String("code", event.Code))
+    }
+    return attrs
+}
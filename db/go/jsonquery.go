@@ -0,0 +1,300 @@
+package db
+
+import (
+    "bytes"
+    "context"
+    "encoding/base64"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "regexp"
+    "strings"
+
+    STRC "github.com/IngenieroRicardo/db/STRUCTURES"
+)
+
+var (
+    jsonRegex   = regexp.MustCompile(`(?i)\(JSON\[([a-z0-9_,BLOB()\s]+)\]`)
+    blobPattern = regexp.MustCompile(`(?i)BLOB\(([a-z0-9_]+)\)`)
+
+    callPattern    = regexp.MustCompile(`(?i)^(?:call|exec|execute)\s+([a-z0-9_]+(?:\.[a-z0-9_]+)?)\s*\(JSON\[([a-z0-9_,BLOB()\s]+)\]\)$`)
+    insertcPattern = regexp.MustCompile(`(?i)^insert\s+into\s+([a-z0-9_.]+(?:\.[a-z0-9_]+)?)\s*\(([a-z0-9_,\sBLOB()]+)\)\s*values\s*\(JSON\[([a-z0-9_,BLOB()\s]+)\]\)$`)
+    insertPattern  = regexp.MustCompile(`(?i)^insert\s+into\s+([a-z0-9_.]+(?:\.[a-z0-9_]+)?)\s*values\s*\(JSON\[([a-z0-9_,BLOB()\s]+)\]\)$`)
+    selectPattern  = regexp.MustCompile(`(?i)^select\s+([a-z0-9_]+(?:\.[a-z0-9_]+)?)\s*\(JSON\[([a-z0-9_,BLOB()\s]+)\]\)$`)
+)
+
+// runJSONQuery executes a JSON[...] shorthand query and reports the batch metadata
+func runJSONQuery(ctx context.Context, q execer, d Dialect, query, jsonStr string) STRC.InternalResult {
+    result, err := runSQLInternal(ctx, q, d, query, jsonStr)
+    if err != nil {
+        return errorResult(ctx, d, "", err)
+    }
+
+    if len(result) == 0 {
+        return STRC.InternalResult{
+            Json:     `{"message":"no data found"}`,
+            Is_error: 0,
+            Is_empty: 1,
+        }
+    }
+
+    firstItem := result[0]
+    firstItemJson, err := json.Marshal(firstItem)
+    if err != nil {
+        errorJson, _ := json.Marshal(STRC.ErrorResponse{Error: err.Error()})
+        return STRC.InternalResult{
+            Json:     string(errorJson),
+            Is_error: 1,
+            Is_empty: 0,
+        }
+    }
+
+    return STRC.InternalResult{
+        Json:     string(firstItemJson),
+        Is_error: 0,
+        Is_empty: 0,
+        Rows:     int64(len(result)),
+    }
+}
+
+// Función interna que mantiene la lógica original
+func runSQLInternal(ctx context.Context, q execer, d Dialect, query string, jsonStr string) ([]map[string]interface{}, error) {
+    normalizedQuery := strings.TrimSpace(strings.TrimSuffix(query, ";"))
+
+    queryType, params, blobParams, err := parseQuery(normalizedQuery)
+    if err != nil {
+        return nil, err
+    }
+
+    var jsonArray []map[string]interface{}
+    var jsonObject map[string]interface{}
+
+    if strings.TrimSpace(jsonStr) != "" && strings.TrimSpace(jsonStr)[0] == '[' {
+        if err := json.Unmarshal([]byte(jsonStr), &jsonArray); err != nil {
+            return nil, fmt.Errorf("error al parsear JSON array: %v", err)
+        }
+        if len(jsonArray) == 0 {
+            return nil, errors.New("el array JSON está vacío")
+        }
+        if err := validateParams(params, blobParams, jsonArray[0]); err != nil {
+            return nil, err
+        }
+    } else if strings.TrimSpace(jsonStr) != "" {
+        if err := json.Unmarshal([]byte(jsonStr), &jsonObject); err != nil {
+            return nil, fmt.Errorf("error al parsear JSON: %v", err)
+        }
+        if err := validateParams(params, blobParams, jsonObject); err != nil {
+            return nil, err
+        }
+        jsonArray = []map[string]interface{}{jsonObject}
+    } else {
+        // Si no hay JSON, creamos un array vacío con un objeto vacío
+        jsonArray = []map[string]interface{}{make(map[string]interface{})}
+    }
+
+    baseQuery := buildQuery(d, queryType, params)
+
+    if db, ok := q.(txBeginner); ok {
+        return executeBatchInsert(ctx, db, baseQuery, params, blobParams, jsonArray)
+    }
+    // Ya estamos dentro de una transacción del llamador: no abrimos otra
+    return executeBatch(ctx, q, baseQuery, params, blobParams, jsonArray)
+}
+
+// parseQuery identifica el tipo de consulta y extrae parámetros normales y BLOB
+func parseQuery(query string) (string, []string, []string, error) {
+    normalizedQuery := strings.TrimSpace(strings.TrimSuffix(query, ";"))
+
+    patterns := []struct {
+        regex     *regexp.Regexp
+        queryType string
+    }{
+        {
+            callPattern,
+            "call",
+        },
+        {
+            insertcPattern,
+            "insert_with_columns",
+        },
+        {
+            insertPattern,
+            "insert_without_columns",
+        },
+        {
+            selectPattern,
+            "select_function",
+        },
+    }
+
+    for _, pattern := range patterns {
+        matches := pattern.regex.FindStringSubmatch(normalizedQuery)
+        if len(matches) > 0 {
+            paramStr := matches[len(matches)-1]
+
+            // Extraer parámetros BLOB primero
+            blobMatches := blobPattern.FindAllStringSubmatch(paramStr, -1)
+            blobParams := make([]string, 0)
+            for _, m := range blobMatches {
+                blobParams = append(blobParams, m[1])
+                // Eliminar los BLOB() de la cadena para procesar los parámetros normales
+                paramStr = strings.Replace(paramStr, m[0], m[1], 1)
+            }
+
+            // Procesar parámetros normales
+            params := strings.Split(paramStr, ",")
+            for i := range params {
+                params[i] = strings.TrimSpace(params[i])
+            }
+
+            switch pattern.queryType {
+            case "insert_with_columns":
+                columns := strings.Split(matches[2], ",")
+                for i := range columns {
+                    columns[i] = strings.TrimSpace(columns[i])
+                }
+                return fmt.Sprintf("%s:%s:%s", pattern.queryType, matches[1], strings.Join(columns, ",")), params, blobParams, nil
+
+            case "select_function_alias":
+                return fmt.Sprintf("%s:%s:%s", pattern.queryType, matches[1], matches[3]), params, blobParams, nil
+
+            default:
+                return fmt.Sprintf("%s:%s", pattern.queryType, matches[1]), params, blobParams, nil
+            }
+        }
+    }
+
+    return "", nil, nil, errors.New("formato de consulta no soportado")
+}
+
+// buildQuery construye la consulta SQL con los placeholders del dialecto
+func buildQuery(d Dialect, queryType string, params []string) string {
+    parts := strings.Split(queryType, ":")
+    qType := parts[0]
+
+    marks := make([]string, len(params))
+    for i := range marks {
+        marks[i] = d.Placeholder(i + 1)
+    }
+    placeholders := strings.Join(marks, ",")
+
+    switch qType {
+    case "call":
+        return d.CallProcedure(parts[1], marks)
+    case "insert_with_columns":
+        return fmt.Sprintf("INSERT INTO %s(%s) VALUES(%s)", parts[1], parts[2], placeholders)
+    case "insert_without_columns":
+        return fmt.Sprintf("INSERT INTO %s VALUES(%s)", parts[1], placeholders)
+    case "select_function":
+        return d.SelectFunction(parts[1], marks, "")
+    case "select_function_alias":
+        return d.SelectFunction(parts[1], marks, parts[2])
+    default:
+        return ""
+    }
+}
+
+// validateParams valida que los parámetros existan en el JSON
+func validateParams(params []string, blobParams []string, jsonData map[string]interface{}) error {
+    for _, param := range params {
+        if _, exists := jsonData[param]; !exists {
+            return fmt.Errorf("parámetro faltante en JSON: '%s'", param)
+        }
+    }
+    return nil
+}
+
+func executeBatchInsert(ctx context.Context, db txBeginner, baseQuery string, params []string, blobParams []string, jsonArray []map[string]interface{}) ([]map[string]interface{}, error) {
+    tx, err := db.BeginTx(ctx, nil)
+    if err != nil {
+        return nil, fmt.Errorf("error al iniciar transacción: %w", err)
+    }
+
+    result, err := executeBatch(ctx, tx, baseQuery, params, blobParams, jsonArray)
+    if err != nil {
+        tx.Rollback()
+        return nil, err
+    }
+
+    if err := tx.Commit(); err != nil {
+        return nil, fmt.Errorf("error al confirmar transacción: %w", err)
+    }
+
+    return result, nil
+}
+
+// executeBatch ejecuta la consulta preparada una vez por cada elemento del JSON
+func executeBatch(ctx context.Context, q execer, baseQuery string, params []string, blobParams []string, jsonArray []map[string]interface{}) ([]map[string]interface{}, error) {
+    stmt, err := q.PrepareContext(ctx, baseQuery)
+    if err != nil {
+        return nil, fmt.Errorf("error al preparar consulta: %w", err)
+    }
+    defer stmt.Close()
+
+    var totalRows int64
+    var lastInsertId int64
+
+    for i, item := range jsonArray {
+        args := make([]interface{}, len(params))
+        for j, param := range params {
+            if isBlobParam(param, blobParams) {
+                // Manejo mejorado para BLOBs
+                val, exists := item[param]
+                if !exists || val == nil {
+                    args[j] = nil
+                    continue
+                }
+
+                strVal, ok := val.(string)
+                if !ok {
+                    return nil, fmt.Errorf("el valor para BLOB %s debe ser string (base64) o null", param)
+                }
+
+                // Decodificación estricta de base64
+                decoded, err := base64.StdEncoding.DecodeString(strVal)
+                if err != nil {
+                    return nil, fmt.Errorf("error decodificando base64 para %s: %v", param, err)
+                }
+                args[j] = decoded
+            } else {
+                args[j] = item[param]
+            }
+        }
+
+        res, err := stmt.ExecContext(ctx, args...)
+        if err != nil {
+            return nil, fmt.Errorf("error al insertar registro %d: %w", i+1, err)
+        }
+
+        if i == 0 {
+            lastInsertId, _ = res.LastInsertId()
+        }
+        rowsAffected, _ := res.RowsAffected()
+        totalRows += rowsAffected
+    }
+
+    return []map[string]interface{}{
+        {
+            "last_insert_id":   lastInsertId,
+            "rows_affected":    totalRows,
+            "records_inserted": len(jsonArray),
+        },
+    }, nil
+}
+
+// Función auxiliar para verificar si un parámetro es BLOB
+func isBlobParam(param string, blobParams []string) bool {
+    for _, p := range blobParams {
+        if p == param {
+            return true
+        }
+    }
+    return false
+}
+
+func isJSON(jsonStr string) bool {
+    decoder := json.NewDecoder(bytes.NewReader([]byte(jsonStr)))
+    decoder.UseNumber()
+    var dummy interface{}
+    return decoder.Decode(&dummy) == nil
+}
@@ -0,0 +1,526 @@
+package db
+
+import (
+    "context"
+    "database/sql"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "os"
+    "path/filepath"
+    "regexp"
+    "sort"
+    "strconv"
+    "time"
+
+    STRC "github.com/IngenieroRicardo/db/STRUCTURES"
+)
+
+// MigrationsTable records the migrations applied to the database
+const MigrationsTable = "schema_migrations"
+
+// MigrationDialect is implemented by dialects that can serialize migrations
+// between processes. The lock is taken on conn and released on the same
+// connection; TransactionalDDL reports whether a migration file can run
+// inside a transaction, so a failing file leaves no partial changes.
+type MigrationDialect interface {
+    TransactionalDDL() bool
+    LockMigrations(ctx context.Context, conn *sql.Conn) error
+    UnlockMigrations(ctx context.Context, conn *sql.Conn) error
+}
+
+var migrationFileName = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// migrationFile pairs the up and down scripts of one version
+type migrationFile struct {
+    version int64
+    name    string
+    up      string
+    down    string
+}
+
+// MigrateUp applies, in version order, every NNNN_name.up.sql file of dir that
+// is not recorded in MigrationsTable. It returns the migrations applied.
+func MigrateUp(connector *Connector, dir string) STRC.InternalResult {
+    files, err := readMigrations(dir)
+    if err != nil {
+        return migrationError(connector, err)
+    }
+    if err := connector.allows(migrationsTableSQL(connector.dialect)); err != nil {
+        return migrationError(connector, err)
+    }
+
+    var done []STRC.MigrationInfo
+    err = withMigrationLock(connector, func(ctx context.Context, conn *sql.Conn) error {
+        if err := createMigrationsTable(ctx, conn, connector.dialect); err != nil {
+            return err
+        }
+        applied, err := appliedMigrations(ctx, conn, connector.dialect)
+        if err != nil {
+            return err
+        }
+
+        for _, f := range files {
+            if _, ok := applied[f.version]; ok {
+                continue
+            }
+            if f.up == "" {
+                return fmt.Errorf("falta el archivo .up.sql de la migración %d", f.version)
+            }
+            appliedAt := time.Now().UTC().Format(time.RFC3339)
+            record := fmt.Sprintf("INSERT INTO %s (version, name, applied_at) VALUES (%s, %s, %s)", MigrationsTable,
+                connector.dialect.Placeholder(1), connector.dialect.Placeholder(2), connector.dialect.Placeholder(3))
+            if err := runMigration(ctx, conn, connector, f, f.up, record, f.version, f.name, appliedAt); err != nil {
+                return err
+            }
+            done = append(done, STRC.MigrationInfo{Version: f.version, Name: f.name, Applied: true, AppliedAt: appliedAt})
+        }
+        return nil
+    })
+    // Aunque falle, parte del esquema pudo cambiar
+    connector.cachedResults().invalidate()
+    if err != nil {
+        return migrationError(connector, err)
+    }
+
+    return migrationResult(done)
+}
+
+// MigrateDown reverts the last n applied migrations, newest first, with their
+// .down.sql files. It returns the migrations reverted.
+func MigrateDown(connector *Connector, dir string, n int) STRC.InternalResult {
+    if n <= 0 {
+        return migrationError(connector, fmt.Errorf("cantidad de migraciones a revertir inválida: %d", n))
+    }
+    files, err := readMigrations(dir)
+    if err != nil {
+        return migrationError(connector, err)
+    }
+    if err := connector.allows(migrationsTableSQL(connector.dialect)); err != nil {
+        return migrationError(connector, err)
+    }
+    byVersion := make(map[int64]migrationFile, len(files))
+    for _, f := range files {
+        byVersion[f.version] = f
+    }
+
+    var done []STRC.MigrationInfo
+    err = withMigrationLock(connector, func(ctx context.Context, conn *sql.Conn) error {
+        if err := createMigrationsTable(ctx, conn, connector.dialect); err != nil {
+            return err
+        }
+        applied, err := appliedMigrations(ctx, conn, connector.dialect)
+        if err != nil {
+            return err
+        }
+
+        versions := make([]int64, 0, len(applied))
+        for version := range applied {
+            versions = append(versions, version)
+        }
+        sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+        if len(versions) > n {
+            versions = versions[:n]
+        }
+
+        for _, version := range versions {
+            f, ok := byVersion[version]
+            if !ok || f.down == "" {
+                return fmt.Errorf("falta el archivo .down.sql de la migración %d", version)
+            }
+            record := fmt.Sprintf("DELETE FROM %s WHERE version = %s", MigrationsTable, connector.dialect.Placeholder(1))
+            if err := runMigration(ctx, conn, connector, f, f.down, record, f.version); err != nil {
+                return err
+            }
+            done = append(done, STRC.MigrationInfo{Version: f.version, Name: f.name, Applied: false})
+        }
+        return nil
+    })
+    // Aunque falle, parte del esquema pudo cambiar
+    connector.cachedResults().invalidate()
+    if err != nil {
+        return migrationError(connector, err)
+    }
+
+    return migrationResult(done)
+}
+
+// MigrationStatus lists every migration found in dir or recorded in the
+// database, in version order, telling which ones are applied. It only reads:
+// without MigrationsTable every migration is reported as pending.
+func MigrationStatus(connector *Connector, dir string) STRC.InternalResult {
+    files, err := readMigrations(dir)
+    if err != nil {
+        return migrationError(connector, err)
+    }
+
+    db, err := connector.handle()
+    if err != nil {
+        return migrationError(connector, err)
+    }
+
+    ctx := context.Background()
+    conn, err := db.Conn(ctx)
+    if err != nil {
+        return migrationError(connector, fmt.Errorf("error al obtener conexión: %w", err))
+    }
+    defer conn.Close()
+
+    applied, err := appliedMigrations(ctx, conn, connector.dialect)
+    if err != nil {
+        return migrationError(connector, err)
+    }
+
+    status := make([]STRC.MigrationInfo, 0, len(files)+len(applied))
+    for _, f := range files {
+        info := STRC.MigrationInfo{Version: f.version, Name: f.name}
+        if a, ok := applied[f.version]; ok {
+            info.Applied = true
+            info.AppliedAt = a.AppliedAt
+            delete(applied, f.version)
+        }
+        status = append(status, info)
+    }
+    // Migraciones registradas cuyo archivo ya no existe
+    for _, a := range applied {
+        status = append(status, a)
+    }
+    sort.Slice(status, func(i, j int) bool { return status[i].Version < status[j].Version })
+
+    return migrationResult(status)
+}
+
+// readMigrations collects the migration files of dir ordered by version
+func readMigrations(dir string) ([]migrationFile, error) {
+    entries, err := os.ReadDir(dir)
+    if err != nil {
+        return nil, fmt.Errorf("error al leer el directorio de migraciones: %w", err)
+    }
+
+    byVersion := make(map[int64]*migrationFile)
+    for _, entry := range entries {
+        m := migrationFileName.FindStringSubmatch(entry.Name())
+        if entry.IsDir() || m == nil {
+            continue
+        }
+        version, err := strconv.ParseInt(m[1], 10, 64)
+        if err != nil {
+            return nil, fmt.Errorf("versión de migración inválida: %s", entry.Name())
+        }
+
+        f, ok := byVersion[version]
+        if !ok {
+            f = &migrationFile{version: version, name: m[2]}
+            byVersion[version] = f
+        } else if f.name != m[2] {
+            return nil, fmt.Errorf("la versión %d está repetida: %s y %s", version, f.name, m[2])
+        }
+        path := filepath.Join(dir, entry.Name())
+        if m[3] == "up" {
+            f.up = path
+        } else {
+            f.down = path
+        }
+    }
+
+    files := make([]migrationFile, 0, len(byVersion))
+    for _, f := range byVersion {
+        files = append(files, *f)
+    }
+    sort.Slice(files, func(i, j int) bool { return files[i].version < files[j].version })
+    return files, nil
+}
+
+// withMigrationLock runs fn on a dedicated connection holding the dialect migration lock
+func withMigrationLock(connector *Connector, fn func(ctx context.Context, conn *sql.Conn) error) error {
+    db, err := connector.handle()
+    if err != nil {
+        return err
+    }
+
+    ctx := context.Background()
+    conn, err := db.Conn(ctx)
+    if err != nil {
+        return fmt.Errorf("error al obtener conexión: %w", err)
+    }
+    defer conn.Close()
+
+    md, ok := connector.dialect.(MigrationDialect)
+    if !ok {
+        return fn(ctx, conn)
+    }
+    if err := md.LockMigrations(ctx, conn); err != nil {
+        return fmt.Errorf("error al obtener el bloqueo de migraciones: %w", err)
+    }
+
+    err = fn(ctx, conn)
+    if uerr := md.UnlockMigrations(ctx, conn); err == nil && uerr != nil {
+        err = fmt.Errorf("error al liberar el bloqueo de migraciones: %w", uerr)
+    }
+    return err
+}
+
+// createMigrationsTable creates MigrationsTable when it does not exist
+func createMigrationsTable(ctx context.Context, conn *sql.Conn, d Dialect) error {
+    exists, err := migrationsTableExists(ctx, conn, d)
+    if err != nil || exists {
+        return err
+    }
+    if _, err := conn.ExecContext(ctx, migrationsTableSQL(d)); err != nil {
+        return fmt.Errorf("error al crear la tabla %s: %w", MigrationsTable, err)
+    }
+    return nil
+}
+
+// migrationsTableExists looks MigrationsTable up in the catalog of the engine.
+// Other engines count its rows and take an undefined object error as missing.
+func migrationsTableExists(ctx context.Context, conn *sql.Conn, d Dialect) (bool, error) {
+    var query string
+    switch d.(type) {
+    case sqliteDialect:
+        query = "SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?"
+    case mysqlDialect:
+        query = "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = ?"
+    case postgresDialect:
+        // to_regclass resuelve el nombre con el search_path, como las sentencias de migración
+        query = "SELECT COUNT(*) FROM (SELECT to_regclass($1) AS t) r WHERE t IS NOT NULL"
+    case sqlserverDialect:
+        query = "SELECT COUNT(*) FROM sys.tables WHERE object_id = OBJECT_ID(@p1)"
+    case oracleDialect:
+        query = "SELECT COUNT(*) FROM user_tables WHERE table_name = UPPER(:1)"
+    default:
+        var count int64
+        err := conn.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+MigrationsTable).Scan(&count)
+        if err != nil && describeError(ctx, d, err).Category == CategoryUndefinedObject {
+            return false, nil
+        }
+        if err != nil {
+            return false, fmt.Errorf("error al leer la tabla %s: %w", MigrationsTable, err)
+        }
+        return true, nil
+    }
+
+    var count int64
+    if err := conn.QueryRowContext(ctx, query, MigrationsTable).Scan(&count); err != nil {
+        return false, fmt.Errorf("error al buscar la tabla %s: %w", MigrationsTable, err)
+    }
+    return count > 0, nil
+}
+
+// appliedMigrations reads MigrationsTable by version; a missing table has none
+func appliedMigrations(ctx context.Context, conn *sql.Conn, d Dialect) (map[int64]STRC.MigrationInfo, error) {
+    applied := make(map[int64]STRC.MigrationInfo)
+    exists, err := migrationsTableExists(ctx, conn, d)
+    if err != nil || !exists {
+        return applied, err
+    }
+
+    rows, err := conn.QueryContext(ctx, "SELECT version, name, applied_at FROM "+MigrationsTable)
+    if err != nil {
+        return nil, fmt.Errorf("error al leer la tabla %s: %w", MigrationsTable, err)
+    }
+    defer rows.Close()
+
+    for rows.Next() {
+        info := STRC.MigrationInfo{Applied: true}
+        if err := rows.Scan(&info.Version, &info.Name, &info.AppliedAt); err != nil {
+            return nil, fmt.Errorf("error al leer la tabla %s: %w", MigrationsTable, err)
+        }
+        applied[info.Version] = info
+    }
+    return applied, rows.Err()
+}
+
+func migrationsTableSQL(d Dialect) string {
+    if _, ok := d.(oracleDialect); ok {
+        return "CREATE TABLE " + MigrationsTable + " (version NUMBER(19) NOT NULL PRIMARY KEY, name VARCHAR2(255) NOT NULL, applied_at VARCHAR2(40) NOT NULL)"
+    }
+    return "CREATE TABLE " + MigrationsTable + " (version BIGINT NOT NULL PRIMARY KEY, name VARCHAR(255) NOT NULL, applied_at VARCHAR(40) NOT NULL)"
+}
+
+// runMigration executes the statements of path and the bookkeeping statement
+// record, all in one transaction when the engine has transactional DDL
+func runMigration(ctx context.Context, conn *sql.Conn, connector *Connector, f migrationFile, path string, record string, recordArgs ...any) error {
+    script, err := os.ReadFile(path)
+    if err != nil {
+        return fmt.Errorf("error al leer %s: %w", filepath.Base(path), err)
+    }
+    statements := splitStatements(connector.dialect, string(script))
+    for _, stmt := range statements {
+        if err := connector.allows(stmt); err != nil {
+            return fmt.Errorf("%s: %w", filepath.Base(path), err)
+        }
+    }
+    statements = append(statements, record)
+
+    md, ok := connector.dialect.(MigrationDialect)
+    if !ok || !md.TransactionalDDL() {
+        // Sin DDL transaccional un fallo deja aplicadas las sentencias anteriores
+        return execMigration(ctx, conn, f, statements, recordArgs)
+    }
+    if _, ok := connector.dialect.(sqliteDialect); ok {
+        // LockMigrations dejó abierta la transacción: cada archivo es un savepoint
+        return execMigrationSavepoint(ctx, conn, f, statements, recordArgs)
+    }
+
+    tx, err := conn.BeginTx(ctx, nil)
+    if err != nil {
+        return fmt.Errorf("error al iniciar transacción: %w", err)
+    }
+    if err := execMigration(ctx, tx, f, statements, recordArgs); err != nil {
+        tx.Rollback()
+        return err
+    }
+    if err := tx.Commit(); err != nil {
+        return fmt.Errorf("error al confirmar transacción: %w", err)
+    }
+    return nil
+}
+
+// execMigration runs the statements in order; only the last one, the
+// bookkeeping statement, takes arguments
+func execMigration(ctx context.Context, q execer, f migrationFile, statements []string, recordArgs []any) error {
+    for i, stmt := range statements {
+        var args []any
+        if i == len(statements)-1 {
+            args = recordArgs
+        }
+        if _, err := q.ExecContext(ctx, stmt, args...); err != nil {
+            return fmt.Errorf("migración %d_%s: %w", f.version, f.name, err)
+        }
+    }
+    return nil
+}
+
+// execMigrationSavepoint runs a migration inside a savepoint of the
+// transaction already open on conn and rolls back to it if a statement fails
+func execMigrationSavepoint(ctx context.Context, conn *sql.Conn, f migrationFile, statements []string, recordArgs []any) error {
+    if _, err := conn.ExecContext(ctx, "SAVEPOINT migration"); err != nil {
+        return fmt.Errorf("error al crear el savepoint: %w", err)
+    }
+    if err := execMigration(ctx, conn, f, statements, recordArgs); err != nil {
+        conn.ExecContext(ctx, "ROLLBACK TO SAVEPOINT migration")
+        conn.ExecContext(ctx, "RELEASE SAVEPOINT migration")
+        return err
+    }
+    if _, err := conn.ExecContext(ctx, "RELEASE SAVEPOINT migration"); err != nil {
+        return fmt.Errorf("error al liberar el savepoint: %w", err)
+    }
+    return nil
+}
+
+func migrationResult(migrations []STRC.MigrationInfo) STRC.InternalResult {
+    if len(migrations) == 0 {
+        return STRC.InternalResult{
+            Json:     "[]",
+            Is_error: 0,
+            Is_empty: 1,
+        }
+    }
+    jsonData, _ := json.Marshal(migrations)
+    return STRC.InternalResult{
+        Json:     string(jsonData),
+        Is_error: 0,
+        Is_empty: 0,
+    }
+}
+
+func migrationError(connector *Connector, err error) STRC.InternalResult {
+    return errorResult(context.Background(), connector.dialect, "", err)
+}
+
+var errMigrationLock = errors.New("el bloqueo está tomado por otra instancia")
+
+// SQLite rolls DDL back. BEGIN IMMEDIATE takes the write lock of the database
+// file until the COMMIT of UnlockMigrations, so the files run in savepoints
+// of that transaction.
+func (sqliteDialect) TransactionalDDL() bool { return true }
+
+func (sqliteDialect) LockMigrations(ctx context.Context, conn *sql.Conn) error {
+    _, err := conn.ExecContext(ctx, "BEGIN IMMEDIATE")
+    return err
+}
+
+func (sqliteDialect) UnlockMigrations(ctx context.Context, conn *sql.Conn) error {
+    _, err := conn.ExecContext(ctx, "COMMIT")
+    return err
+}
+
+// MySQL commits DDL implicitly; GET_LOCK waits until the named lock is free
+func (mysqlDialect) TransactionalDDL() bool { return false }
+
+func (mysqlDialect) LockMigrations(ctx context.Context, conn *sql.Conn) error {
+    var got sql.NullInt64
+    if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, -1)", MigrationsTable).Scan(&got); err != nil {
+        return err
+    }
+    if !got.Valid || got.Int64 != 1 {
+        return errMigrationLock
+    }
+    return nil
+}
+
+func (mysqlDialect) UnlockMigrations(ctx context.Context, conn *sql.Conn) error {
+    _, err := conn.ExecContext(ctx, "SELECT RELEASE_LOCK(?)", MigrationsTable)
+    return err
+}
+
+// migrationLockKey identifies the PostgreSQL advisory lock of the migrations
+const migrationLockKey = 0x5344424d4947 // "SDBMIG"
+
+// PostgreSQL rolls DDL back and has session advisory locks
+func (postgresDialect) TransactionalDDL() bool { return true }
+
+func (postgresDialect) LockMigrations(ctx context.Context, conn *sql.Conn) error {
+    _, err := conn.ExecContext(ctx, fmt.Sprintf("SELECT pg_advisory_lock(%d)", migrationLockKey))
+    return err
+}
+
+func (postgresDialect) UnlockMigrations(ctx context.Context, conn *sql.Conn) error {
+    _, err := conn.ExecContext(ctx, fmt.Sprintf("SELECT pg_advisory_unlock(%d)", migrationLockKey))
+    return err
+}
+
+// SQL Server rolls DDL back and locks through sp_getapplock owned by the session
+func (sqlserverDialect) TransactionalDDL() bool { return true }
+
+func (sqlserverDialect) LockMigrations(ctx context.Context, conn *sql.Conn) error {
+    var status int
+    query := "DECLARE @r int; EXEC @r = sp_getapplock @Resource = @p1, @LockMode = 'Exclusive', @LockOwner = 'Session', @LockTimeout = -1; SELECT @r"
+    if err := conn.QueryRowContext(ctx, query, MigrationsTable).Scan(&status); err != nil {
+        return err
+    }
+    if status < 0 {
+        return errMigrationLock
+    }
+    return nil
+}
+
+func (sqlserverDialect) UnlockMigrations(ctx context.Context, conn *sql.Conn) error {
+    _, err := conn.ExecContext(ctx, "EXEC sp_releaseapplock @Resource = @p1, @LockOwner = 'Session'", MigrationsTable)
+    return err
+}
+
+// Oracle commits DDL implicitly; DBMS_LOCK needs EXECUTE on the package
+func (oracleDialect) TransactionalDDL() bool { return false }
+
+func (oracleDialect) LockMigrations(ctx context.Context, conn *sql.Conn) error {
+    _, err := conn.ExecContext(ctx, `DECLARE h VARCHAR2(128); r INTEGER;
+BEGIN
+  DBMS_LOCK.ALLOCATE_UNIQUE('SCHEMA_MIGRATIONS', h);
+  r := DBMS_LOCK.REQUEST(h, DBMS_LOCK.X_MODE, DBMS_LOCK.MAXWAIT, FALSE);
+  IF r NOT IN (0, 4) THEN
+    RAISE_APPLICATION_ERROR(-20001, 'DBMS_LOCK.REQUEST devolvió ' || r);
+  END IF;
+END;`)
+    return err
+}
+
+func (oracleDialect) UnlockMigrations(ctx context.Context, conn *sql.Conn) error {
+    _, err := conn.ExecContext(ctx, `DECLARE h VARCHAR2(128); r INTEGER;
+BEGIN
+  DBMS_LOCK.ALLOCATE_UNIQUE('SCHEMA_MIGRATIONS', h);
+  r := DBMS_LOCK.RELEASE(h);
+END;`)
+    return err
+}
@@ -0,0 +1,100 @@
+package db
+
+import (
+    "os"
+    "path/filepath"
+    "reflect"
+    "testing"
+)
+
+func TestReadMigrations(t *testing.T) {
+    tests := []struct {
+        name    string
+        files   []string
+        want    []migrationFile // up and down hold file names, relative to the directory
+        wantErr bool
+    }{
+        {
+            name:  "ordered by version",
+            files: []string{"10_c.up.sql", "2_b.up.sql", "2_b.down.sql", "1_a.up.sql"},
+            want: []migrationFile{
+                {version: 1, name: "a", up: "1_a.up.sql"},
+                {version: 2, name: "b", up: "2_b.up.sql", down: "2_b.down.sql"},
+                {version: 10, name: "c", up: "10_c.up.sql"},
+            },
+        },
+        {
+            name:  "other files are ignored",
+            files: []string{"1_a.up.sql", "README.md", "a_1.up.sql", "2_b.sql", "3_c.up.txt"},
+            want:  []migrationFile{{version: 1, name: "a", up: "1_a.up.sql"}},
+        },
+        {
+            name:  "name with underscores and dots",
+            files: []string{"0003_add_users.v2.up.sql"},
+            want:  []migrationFile{{version: 3, name: "add_users.v2", up: "0003_add_users.v2.up.sql"}},
+        },
+        {
+            name:  "down without up",
+            files: []string{"1_a.down.sql"},
+            want:  []migrationFile{{version: 1, name: "a", down: "1_a.down.sql"}},
+        },
+        {
+            name:    "repeated version",
+            files:   []string{"1_a.up.sql", "01_b.up.sql"},
+            wantErr: true,
+        },
+        {
+            name:    "version out of range",
+            files:   []string{"99999999999999999999_a.up.sql"},
+            wantErr: true,
+        },
+        {
+            name: "empty directory",
+            want: []migrationFile{},
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            dir := t.TempDir()
+            for _, name := range tt.files {
+                if err := os.WriteFile(filepath.Join(dir, name), []byte("SELECT 1;"), 0o644); err != nil {
+                    t.Fatal(err)
+                }
+            }
+            if err := os.Mkdir(filepath.Join(dir, "4_dir.up.sql"), 0o755); err != nil {
+                t.Fatal(err)
+            }
+
+            got, err := readMigrations(dir)
+            if tt.wantErr {
+                if err == nil {
+                    t.Errorf("readMigrations = %+v, want an error", got)
+                }
+                return
+            }
+            if err != nil {
+                t.Fatal(err)
+            }
+            want := make([]migrationFile, len(tt.want))
+            for i, f := range tt.want {
+                if f.up != "" {
+                    f.up = filepath.Join(dir, f.up)
+                }
+                if f.down != "" {
+                    f.down = filepath.Join(dir, f.down)
+                }
+                want[i] = f
+            }
+            if !reflect.DeepEqual(got, want) {
+                t.Errorf("readMigrations\n got %+v\nwant %+v", got, want)
+            }
+        })
+    }
+}
+
+func TestReadMigrationsMissingDir(t *testing.T) {
+    if _, err := readMigrations(filepath.Join(t.TempDir(), "nope")); err == nil {
+        t.Error("readMigrations of a missing directory did not fail")
+    }
+}
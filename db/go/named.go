@@ -0,0 +1,254 @@
+package db
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "fmt"
+    "strings"
+    "time"
+
+    ARGS "github.com/IngenieroRicardo/db/ARGS"
+    ENCODER "github.com/IngenieroRicardo/db/ENCODER"
+    STRC "github.com/IngenieroRicardo/db/STRUCTURES"
+)
+
+// parseNamed replaces the :name and @name parameters of query with the
+// placeholders of the dialect and returns the parameter names in order. A name
+// used twice gets two placeholders. Literals, quoted identifiers, comments and
+// dollar-quoted bodies, as the engine of d reads them, :: casts, := assignments
+// and @@ variables are left alone.
+func parseNamed(d Dialect, query string) (string, []string) {
+    var out strings.Builder
+    var names []string
+
+    x := syntaxFor(d)
+    n := len(query)
+    for i := 0; i < n; {
+        if sp := x.span(query, i); sp.kind != noSpan {
+            out.WriteString(query[i:sp.end])
+            i = sp.end
+            continue
+        }
+
+        c := query[i]
+        switch {
+        case (c == ':' || c == '@') && i+1 < n && query[i+1] == c:
+            // :: cast or @@ system variable
+            j := i + 2
+            for j < n && isIdentChar(query[j]) {
+                j++
+            }
+            out.WriteString(query[i:j])
+            i = j
+        case (c == ':' || c == '@') && i+1 < n && isIdentStart(query[i+1]) && (i == 0 || !isIdentChar(query[i-1])):
+            j := i + 1
+            for j < n && isIdentChar(query[j]) {
+                j++
+            }
+            names = append(names, query[i+1:j])
+            out.WriteString(d.Placeholder(len(names)))
+            i = j
+        default:
+            out.WriteByte(c)
+            i++
+        }
+    }
+
+    return out.String(), names
+}
+
+func isIdentStart(c byte) bool {
+    return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentChar(c byte) bool {
+    return isIdentStart(c) || isDigit(c)
+}
+
+func isDigit(c byte) bool {
+    return c >= '0' && c <= '9'
+}
+
+// runNamed binds the named parameters from a JSON object, or runs the
+// statement once per element of a JSON array
+func runNamed(ctx context.Context, q execer, d Dialect, mode ENCODER.Mode, query string, names []string, jsonStr string) STRC.InternalResult {
+    decoder := json.NewDecoder(strings.NewReader(jsonStr))
+    decoder.UseNumber()
+
+    if strings.TrimSpace(jsonStr)[0] == '{' {
+        var object map[string]interface{}
+        if err := decoder.Decode(&object); err != nil {
+            return STRC.InternalResult{
+                Json:     createErrorJSON(fmt.Sprintf("error al parsear JSON: %v", err)),
+                Is_error: 1,
+                Is_empty: 0,
+            }
+        }
+        args, err := namedArgs(names, object)
+        if err != nil {
+            return STRC.InternalResult{
+                Json:     createErrorJSON(err.Error()),
+                Is_error: 1,
+                Is_empty: 0,
+            }
+        }
+        return runStatement(ctx, q, d, mode, query, args...)
+    }
+
+    var array []map[string]interface{}
+    if err := decoder.Decode(&array); err != nil {
+        return STRC.InternalResult{
+            Json:     createErrorJSON(fmt.Sprintf("error al parsear JSON array: %v", err)),
+            Is_error: 1,
+            Is_empty: 0,
+        }
+    }
+    if len(array) == 0 {
+        return STRC.InternalResult{
+            Json:     createErrorJSON("el array JSON está vacío"),
+            Is_error: 1,
+            Is_empty: 0,
+        }
+    }
+
+    start := time.Now()
+    var total int64
+    var err error
+    if db, ok := q.(txBeginner); ok {
+        total, err = execNamedBatchTx(ctx, db, query, names, array)
+    } else {
+        // Ya estamos dentro de una transacción del llamador: no abrimos otra
+        total, err = execNamedBatch(ctx, q, query, names, array)
+    }
+    if err != nil {
+        return errorResult(ctx, d, "", err)
+    }
+
+    jsonData, _ := json.Marshal(STRC.SuccessResponse{
+        Status:       "OK",
+        Rows:         int64(len(array)),
+        RowsAffected: &total,
+        ElapsedMs:    elapsedMs(time.Since(start)),
+    })
+    return STRC.InternalResult{
+        Json:     string(jsonData),
+        Is_error: 0,
+        Is_empty: 1,
+        Rows:     total,
+    }
+}
+
+func execNamedBatchTx(ctx context.Context, db txBeginner, query string, names []string, array []map[string]interface{}) (int64, error) {
+    tx, err := db.BeginTx(ctx, nil)
+    if err != nil {
+        return 0, fmt.Errorf("error al iniciar transacción: %w", err)
+    }
+
+    total, err := execNamedBatch(ctx, tx, query, names, array)
+    if err != nil {
+        tx.Rollback()
+        return 0, err
+    }
+
+    if err := tx.Commit(); err != nil {
+        return 0, fmt.Errorf("error al confirmar transacción: %w", err)
+    }
+    return total, nil
+}
+
+// execNamedBatch prepares query once and executes it for every element, returning the rows affected
+func execNamedBatch(ctx context.Context, q execer, query string, names []string, array []map[string]interface{}) (int64, error) {
+    stmt, err := q.PrepareContext(ctx, query)
+    if err != nil {
+        return 0, fmt.Errorf("error al preparar consulta: %w", err)
+    }
+    defer stmt.Close()
+
+    var total int64
+    for i, item := range array {
+        args, err := namedArgs(names, item)
+        if err != nil {
+            return 0, fmt.Errorf("registro %d: %w", i+1, err)
+        }
+        res, err := stmt.ExecContext(ctx, args...)
+        if err != nil {
+            return 0, fmt.Errorf("error al ejecutar registro %d: %w", i+1, err)
+        }
+        rowsAffected, _ := res.RowsAffected()
+        total += rowsAffected
+    }
+    return total, nil
+}
+
+// bindNamed binds a named:: JSON object given as the single argument of a
+// statement that runs once, such as the query of a cursor, and returns the
+// rewritten query with its positional arguments. Other arguments are returned
+// as they are.
+func bindNamed(d Dialect, query string, args []any) (string, []any, error) {
+    var params ARGS.Named
+    for _, arg := range args {
+        if p, ok := arg.(ARGS.Named); ok {
+            params = p
+        }
+    }
+    switch {
+    case params == "":
+        return query, args, nil
+    case len(args) > 1:
+        return "", nil, fmt.Errorf("named:: debe ser el único argumento de la consulta")
+    case params[0] != '{':
+        return "", nil, fmt.Errorf("named:: solo admite un objeto JSON en esta operación")
+    }
+
+    namedQuery, names := parseNamed(d, query)
+    if len(names) == 0 {
+        return "", nil, fmt.Errorf("la consulta no tiene parámetros :nombre ni @nombre")
+    }
+    decoder := json.NewDecoder(strings.NewReader(string(params)))
+    decoder.UseNumber()
+    var object map[string]interface{}
+    if err := decoder.Decode(&object); err != nil {
+        return "", nil, fmt.Errorf("error al parsear JSON: %v", err)
+    }
+    bound, err := namedArgs(names, object)
+    if err != nil {
+        return "", nil, err
+    }
+    return namedQuery, bound, nil
+}
+
+// namedArgs takes the value of every name from object. Numbers become int64 or
+// float64 and nested objects or arrays are passed as their JSON text.
+func namedArgs(names []string, object map[string]interface{}) ([]interface{}, error) {
+    args := make([]interface{}, len(names))
+    for i, name := range names {
+        value, exists := object[name]
+        if !exists {
+            return nil, fmt.Errorf("parámetro faltante en JSON: '%s'", name)
+        }
+        args[i] = jsonArg(value)
+    }
+    return args, nil
+}
+
+// jsonArg converts a value decoded with UseNumber into a driver value:
+// integers become int64, other numbers float64, objects and arrays JSON text
+func jsonArg(value interface{}) interface{} {
+    switch v := value.(type) {
+    case json.Number:
+        if n, err := v.Int64(); err == nil {
+            return n
+        } else if f, err := v.Float64(); err == nil {
+            return f
+        }
+        return v.String()
+    case map[string]interface{}, []interface{}:
+        var buf bytes.Buffer
+        encoder := json.NewEncoder(&buf)
+        encoder.SetEscapeHTML(false)
+        encoder.Encode(v)
+        return strings.TrimSuffix(buf.String(), "\n")
+    }
+    return value
+}
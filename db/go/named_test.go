@@ -0,0 +1,149 @@
+package db
+
+import (
+    "reflect"
+    "testing"
+
+    ARGS "github.com/IngenieroRicardo/db/ARGS"
+)
+
+func TestParseNamed(t *testing.T) {
+    tests := []struct {
+        name   string
+        driver string
+        query  string
+        want   string
+        names  []string
+    }{
+        {
+            name:   "colon and at",
+            driver: "sqlite3",
+            query:  "SELECT * FROM t WHERE a = :a AND b = @b",
+            want:   "SELECT * FROM t WHERE a = ? AND b = ?",
+            names:  []string{"a", "b"},
+        },
+        {
+            name:   "name used twice",
+            driver: "postgres",
+            query:  "SELECT * FROM t WHERE a = :a OR b = :a",
+            want:   "SELECT * FROM t WHERE a = $1 OR b = $2",
+            names:  []string{"a", "a"},
+        },
+        {
+            name:   "casts, literals, identifiers and comments",
+            driver: "postgres",
+            query:  "SELECT a::text, ':x', \":y\" FROM t WHERE c = :c -- :z\n/* :w */",
+            want:   "SELECT a::text, ':x', \":y\" FROM t WHERE c = $1 -- :z\n/* :w */",
+            names:  []string{"c"},
+        },
+        {
+            name:   "dollar quotes",
+            driver: "postgres",
+            query:  "SELECT $$ :x $$, $t$ :y $t$ FROM t WHERE id = :id",
+            want:   "SELECT $$ :x $$, $t$ :y $t$ FROM t WHERE id = $1",
+            names:  []string{"id"},
+        },
+        {
+            name:   "mysql system variables, escapes and hash comments",
+            driver: "mysql",
+            query:  "SELECT @@version, 'it\\'s :x' FROM t WHERE id = :id # :z",
+            want:   "SELECT @@version, 'it\\'s :x' FROM t WHERE id = ? # :z",
+            names:  []string{"id"},
+        },
+        {
+            name:   "sqlserver brackets",
+            driver: "sqlserver",
+            query:  "SELECT [:x] FROM t WHERE a = @a AND b = @b",
+            want:   "SELECT [:x] FROM t WHERE a = @p1 AND b = @p2",
+            names:  []string{"a", "b"},
+        },
+        {
+            name:   "oracle q quote",
+            driver: "oracle",
+            query:  "SELECT q'[:x]' FROM dual WHERE a = :a",
+            want:   "SELECT q'[:x]' FROM dual WHERE a = :1",
+            names:  []string{"a"},
+        },
+        {
+            name:   "colon inside a word",
+            driver: "sqlite3",
+            query:  "SELECT 1 WHERE x = a:b",
+            want:   "SELECT 1 WHERE x = a:b",
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, names := parseNamed(dialectFor(tt.driver), tt.query)
+            if got != tt.want || !reflect.DeepEqual(names, tt.names) {
+                t.Errorf("parseNamed(%q)\n got %q %q\nwant %q %q", tt.query, got, names, tt.want, tt.names)
+            }
+        })
+    }
+}
+
+func TestBindNamed(t *testing.T) {
+    d := dialectFor("postgres")
+    tests := []struct {
+        name    string
+        query   string
+        args    []any
+        want    string
+        bound   []any
+        wantErr bool
+    }{
+        {
+            name:  "no named argument",
+            query: "SELECT $1",
+            args:  []any{int64(1)},
+            want:  "SELECT $1",
+            bound: []any{int64(1)},
+        },
+        {
+            name:  "object",
+            query: "SELECT * FROM t WHERE a = :a AND b = :b",
+            args:  []any{ARGS.Named(`{"a": 1, "b": "x"}`)},
+            want:  "SELECT * FROM t WHERE a = $1 AND b = $2",
+            bound: []any{int64(1), "x"},
+        },
+        {
+            name:    "missing key",
+            query:   "SELECT * FROM t WHERE a = :a",
+            args:    []any{ARGS.Named(`{"b": 1}`)},
+            wantErr: true,
+        },
+        {
+            name:    "array",
+            query:   "SELECT * FROM t WHERE a = :a",
+            args:    []any{ARGS.Named(`[{"a": 1}]`)},
+            wantErr: true,
+        },
+        {
+            name:    "not the only argument",
+            query:   "SELECT * FROM t WHERE a = :a AND b = $2",
+            args:    []any{ARGS.Named(`{"a": 1}`), int64(2)},
+            wantErr: true,
+        },
+        {
+            name:    "no parameters",
+            query:   "SELECT 1",
+            args:    []any{ARGS.Named(`{"a": 1}`)},
+            wantErr: true,
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, bound, err := bindNamed(d, tt.query, tt.args)
+            if tt.wantErr {
+                if err == nil {
+                    t.Errorf("bindNamed(%q) = %q, want an error", tt.query, got)
+                }
+                return
+            }
+            if err != nil || got != tt.want || !reflect.DeepEqual(bound, tt.bound) {
+                t.Errorf("bindNamed(%q) = %q %v %v, want %q %v", tt.query, got, bound, err, tt.want, tt.bound)
+            }
+        })
+    }
+}
@@ -0,0 +1,496 @@
+package db
+
+import (
+    "context"
+    "database/sql"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "regexp"
+    "strconv"
+    "strings"
+    "time"
+
+    ARGS "github.com/IngenieroRicardo/db/ARGS"
+    ENCODER "github.com/IngenieroRicardo/db/ENCODER"
+    STRC "github.com/IngenieroRicardo/db/STRUCTURES"
+)
+
+// Parameter modes accepted in ProcedureParam.Mode
+const (
+    ParamIn    = "IN"
+    ParamOut   = "OUT"
+    ParamInOut = "INOUT"
+)
+
+// procedureName accepts procedure, schema.procedure and package.schema.procedure
+var procedureName = regexp.MustCompile(`(?i)^[a-z_][a-z0-9_$#]*(?:\.[a-z_][a-z0-9_$#]*){0,2}$`)
+
+// ProcedureParam describes one argument of CallProcedure. Type is one of the
+// ARGS prefixes (int, float, double, bool, decimal, date, datetime, uuid, json,
+// blob) or empty for text; it converts IN values and sets the Go type that
+// receives OUT values.
+type ProcedureParam struct {
+    Name  string      `json:"name"`
+    Mode  string      `json:"mode"`
+    Type  string      `json:"type"`
+    Value interface{} `json:"value"`
+}
+
+func (p ProcedureParam) isOut() bool {
+    return p.Mode == ParamOut || p.Mode == ParamInOut
+}
+
+// CallProcedure calls a stored procedure with the parameters described by
+// the JSON array params and answers {"resultsets":[...],"out":{"name":value}}.
+// OUT and INOUT parameters are bound with sql.Out on SQL Server and Oracle,
+// through session variables on MySQL and read from the row returned by CALL
+// on PostgreSQL.
+func CallProcedure(connector *Connector, name string, params string) STRC.InternalResult {
+    if !procedureName.MatchString(name) {
+        return procedureError(fmt.Sprintf("nombre de procedimiento no válido: %q", name))
+    }
+    call := connector.dialect.CallProcedure(name, nil)
+    if err := connector.allows(call); err != nil {
+        return errorResult(context.Background(), connector.dialect, "", err)
+    }
+
+    var list []ProcedureParam
+    if strings.TrimSpace(params) != "" {
+        decoder := json.NewDecoder(strings.NewReader(params))
+        decoder.UseNumber()
+        if err := decoder.Decode(&list); err != nil {
+            return procedureError(fmt.Sprintf("error al parsear JSON: %v", err))
+        }
+    }
+    for i := range list {
+        list[i].Mode = strings.ToUpper(strings.TrimSpace(list[i].Mode))
+        if list[i].Mode == "" {
+            list[i].Mode = ParamIn
+        }
+        switch list[i].Mode {
+        case ParamIn, ParamOut, ParamInOut:
+        default:
+            return procedureError(fmt.Sprintf("modo de parámetro no válido: %q", list[i].Mode))
+        }
+        if _, err := outDest(connector.dialect, list[i].Type); err != nil {
+            return procedureError(err.Error())
+        }
+        if list[i].isOut() && list[i].Name == "" {
+            return procedureError(fmt.Sprintf("el parámetro %d de salida necesita nombre", i+1))
+        }
+    }
+
+    values := make([]any, len(list))
+    for i, p := range list {
+        values[i] = p.Value
+    }
+    return observe(context.Background(), hooksFor(connector), connector.driver, call, values, func() STRC.InternalResult {
+        return callProcedure(connector, name, list)
+    })
+}
+
+// callProcedure runs the call on one connection of the primary
+func callProcedure(connector *Connector, name string, list []ProcedureParam) STRC.InternalResult {
+    db, err := connector.handle()
+    if err != nil {
+        return procedureError(err.Error())
+    }
+
+    ctx, cancel := statementContext(context.Background(), connector)
+    defer cancel()
+
+    // Las variables de sesión de MySQL exigen una sola conexión
+    conn, err := db.Conn(ctx)
+    if err != nil {
+        return errorResult(ctx, connector.dialect, "error al obtener conexión", err)
+    }
+    defer conn.Close()
+
+    var result STRC.InternalResult
+    switch d := connector.dialect.(type) {
+    case sqlserverDialect, oracleDialect:
+        result = callWithOutBinds(ctx, conn, d, connector.outputMode(ctx), name, list)
+    case mysqlDialect:
+        result = callWithSessionVars(ctx, conn, d, connector.outputMode(ctx), name, list)
+    case postgresDialect:
+        result = callWithResultRow(ctx, conn, d, connector.outputMode(ctx), name, list)
+    default:
+        return procedureError("el motor no soporta procedimientos almacenados")
+    }
+    // Como CALL en SQLrunonLoad: el procedimiento pudo escribir en cualquier tabla, aunque falle
+    connector.cachedResults().invalidate()
+    return result
+}
+
+// callWithOutBinds binds OUT parameters with sql.Out. SQL Server fills them
+// once every result set has been read, so the rows are drained first.
+func callWithOutBinds(ctx context.Context, conn *sql.Conn, d Dialect, mode ENCODER.Mode, name string, params []ProcedureParam) STRC.InternalResult {
+    marks := make([]string, len(params))
+    args := make([]any, len(params))
+    dests := make(map[string]any)
+    _, isSQLServer := d.(sqlserverDialect)
+
+    for i, p := range params {
+        marks[i] = d.Placeholder(i + 1)
+        if !p.isOut() {
+            value, err := inValue(p)
+            if err != nil {
+                return procedureError(err.Error())
+            }
+            args[i] = value
+            continue
+        }
+
+        dest, _ := outDest(d, p.Type)
+        if t, ok := dest.(*sql.NullTime); ok && isSQLServer && p.Mode == ParamOut {
+            // go-mssqldb no puede enviar un NULL de tipo fecha
+            t.Valid = true
+        }
+        if p.Mode == ParamInOut {
+            if err := setInOut(dest, p); err != nil {
+                return procedureError(err.Error())
+            }
+        }
+        dests[p.Name] = dest
+        args[i] = sql.Out{Dest: dest, In: p.Mode == ParamInOut}
+        if isSQLServer {
+            marks[i] += " OUTPUT"
+        }
+    }
+
+    query := d.CallProcedure(name, marks)
+    var sets []STRC.ResultSet
+    if isSQLServer {
+        rows, err := conn.QueryContext(ctx, query, args...)
+        if err != nil {
+            return errorResult(ctx, d, "Error en la consulta SQL", err)
+        }
+        var result STRC.InternalResult
+        var ok bool
+        sets, result, ok = readResultSets(ctx, rows, d, mode)
+        rows.Close()
+        if !ok {
+            return result
+        }
+    } else if _, err := conn.ExecContext(ctx, query, args...); err != nil {
+        return errorResult(ctx, d, "Error en la consulta SQL", err)
+    }
+
+    out := make(map[string]any, len(dests))
+    for name, dest := range dests {
+        out[name] = outValue(dest)
+    }
+    return procedureResult(sets, out)
+}
+
+// callWithSessionVars passes OUT parameters as @_pN session variables on the
+// same connection and selects them after the CALL
+func callWithSessionVars(ctx context.Context, conn *sql.Conn, d Dialect, mode ENCODER.Mode, name string, params []ProcedureParam) STRC.InternalResult {
+    marks := make([]string, len(params))
+    var args []any
+    var outs []ProcedureParam
+    var vars []string
+
+    for i, p := range params {
+        if !p.isOut() {
+            value, err := inValue(p)
+            if err != nil {
+                return procedureError(err.Error())
+            }
+            marks[i] = d.Placeholder(i + 1)
+            args = append(args, value)
+            continue
+        }
+
+        variable := fmt.Sprintf("@_p%d", i+1)
+        // NULL también limpia el valor que dejó una llamada anterior en esta conexión
+        var initial any
+        if p.Mode == ParamInOut {
+            value, err := inValue(p)
+            if err != nil {
+                return procedureError(err.Error())
+            }
+            initial = value
+        }
+        if _, err := conn.ExecContext(ctx, "SET "+variable+" = ?", initial); err != nil {
+            return errorResult(ctx, d, "Error en la consulta SQL", err)
+        }
+        marks[i] = variable
+        outs = append(outs, p)
+        vars = append(vars, variable)
+    }
+
+    rows, err := conn.QueryContext(ctx, d.CallProcedure(name, marks), args...)
+    if err != nil {
+        return errorResult(ctx, d, "Error en la consulta SQL", err)
+    }
+    sets, result, ok := readResultSets(ctx, rows, d, mode)
+    rows.Close()
+    if !ok {
+        return result
+    }
+
+    out := make(map[string]any, len(outs))
+    if len(vars) > 0 {
+        values := make([]sql.NullString, len(vars))
+        dest := make([]any, len(vars))
+        for i := range values {
+            dest[i] = &values[i]
+        }
+        if err := conn.QueryRowContext(ctx, "SELECT "+strings.Join(vars, ", ")).Scan(dest...); err != nil {
+            return errorResult(ctx, d, "Error al leer parámetros de salida", err)
+        }
+        for i, p := range outs {
+            value, err := outFromText(p.Type, values[i])
+            if err != nil {
+                return procedureError(err.Error())
+            }
+            out[p.Name] = value
+        }
+    }
+    return procedureResult(sets, out)
+}
+
+// callWithResultRow passes NULL for the OUT parameters; PostgreSQL answers the
+// CALL with one row holding the OUT and INOUT values in order
+func callWithResultRow(ctx context.Context, conn *sql.Conn, d Dialect, mode ENCODER.Mode, name string, params []ProcedureParam) STRC.InternalResult {
+    marks := make([]string, len(params))
+    args := make([]any, len(params))
+    var outs []ProcedureParam
+
+    for i, p := range params {
+        marks[i] = d.Placeholder(i + 1)
+        if p.Mode == ParamOut {
+            outs = append(outs, p)
+            continue
+        }
+        value, err := inValue(p)
+        if err != nil {
+            return procedureError(err.Error())
+        }
+        args[i] = value
+        if p.Mode == ParamInOut {
+            outs = append(outs, p)
+        }
+    }
+
+    rows, err := conn.QueryContext(ctx, d.CallProcedure(name, marks), args...)
+    if err != nil {
+        return errorResult(ctx, d, "Error en la consulta SQL", err)
+    }
+    defer rows.Close()
+
+    out := make(map[string]any, len(outs))
+    if len(outs) > 0 && rows.Next() {
+        values := make([]sql.NullString, len(outs))
+        dest := make([]any, len(outs))
+        for i := range values {
+            dest[i] = &values[i]
+        }
+        if err := rows.Scan(dest...); err != nil {
+            return errorResult(ctx, d, "Error al leer parámetros de salida", err)
+        }
+        for i, p := range outs {
+            value, err := outFromText(p.Type, values[i])
+            if err != nil {
+                return procedureError(err.Error())
+            }
+            out[p.Name] = value
+        }
+    }
+    if err := rows.Err(); err != nil {
+        return errorResult(ctx, d, "Error después de iterar filas", err)
+    }
+    return procedureResult(nil, out)
+}
+
+// readResultSets reads every result set of rows with its column metadata
+func readResultSets(ctx context.Context, rows *sql.Rows, d Dialect, mode ENCODER.Mode) ([]STRC.ResultSet, STRC.InternalResult, bool) {
+    var sets []STRC.ResultSet
+    for {
+        if columns, _ := rows.Columns(); len(columns) > 0 {
+            set, _, result, ok := envelopeSet(ctx, rows, d, mode)
+            if !ok {
+                return nil, result, false
+            }
+            sets = append(sets, set)
+        }
+        if !rows.NextResultSet() {
+            break
+        }
+    }
+    if err := rows.Err(); err != nil {
+        return nil, errorResult(ctx, d, "Error después de iterar filas", err), false
+    }
+    return sets, STRC.InternalResult{}, true
+}
+
+// inValue converts the value of an IN or INOUT parameter with its type prefix
+func inValue(p ProcedureParam) (any, error) {
+    var text string
+    switch v := p.Value.(type) {
+    case nil:
+        return nil, nil
+    case string:
+        text = v
+    case json.Number:
+        text = v.String()
+    case bool:
+        text = strconv.FormatBool(v)
+    default:
+        if p.Type != "json" && p.Type != "" {
+            return nil, fmt.Errorf("valor no válido para el parámetro %q", p.Name)
+        }
+        return jsonArg(v), nil
+    }
+    if p.Type == "" {
+        return text, nil
+    }
+
+    values, err := ARGS.Parse([]string{p.Type + "::" + text})
+    if err != nil {
+        return nil, err
+    }
+    return values[0], nil
+}
+
+// outDest returns a pointer of the Go type that receives an OUT parameter,
+// nullable wherever the driver of d can bind it. godror cannot bind a
+// sql.NullBool or a sql.NullString: Oracle booleans are read as bool and
+// text as a string that is empty for NULL, which Oracle stores as NULL anyway.
+func outDest(d Dialect, typeName string) (any, error) {
+    _, isOracle := d.(oracleDialect)
+    switch typeName {
+    case "int":
+        return new(sql.NullInt64), nil
+    case "float", "double":
+        return new(sql.NullFloat64), nil
+    case "bool":
+        if isOracle {
+            return new(bool), nil
+        }
+        return new(sql.NullBool), nil
+    case "date", "datetime":
+        return new(sql.NullTime), nil
+    case "blob":
+        return new([]byte), nil
+    case "", "decimal", "uuid", "json", "array":
+        if isOracle {
+            return new(string), nil
+        }
+        return new(sql.NullString), nil
+    }
+    return nil, fmt.Errorf("tipo de parámetro no soportado: %q", typeName)
+}
+
+// setInOut stores the initial value of an INOUT parameter in dest
+func setInOut(dest any, p ProcedureParam) error {
+    value, err := inValue(p)
+    if err != nil || value == nil {
+        return err
+    }
+    switch d := dest.(type) {
+    case *sql.NullInt64:
+        *d = sql.NullInt64{Int64: value.(int64), Valid: true}
+    case *sql.NullFloat64:
+        *d = sql.NullFloat64{Float64: value.(float64), Valid: true}
+    case *sql.NullBool:
+        *d = sql.NullBool{Bool: value.(bool), Valid: true}
+    case *bool:
+        *d = value.(bool)
+    case *sql.NullTime:
+        *d = sql.NullTime{Time: value.(time.Time), Valid: true}
+    case *[]byte:
+        *d = value.([]byte)
+    case *sql.NullString:
+        *d = sql.NullString{String: fmt.Sprint(value), Valid: true}
+    case *string:
+        *d = fmt.Sprint(value)
+    }
+    return nil
+}
+
+// outValue returns the value an OUT parameter received, nil for NULL
+func outValue(dest any) any {
+    switch d := dest.(type) {
+    case *sql.NullInt64:
+        if d.Valid {
+            return d.Int64
+        }
+    case *sql.NullFloat64:
+        if d.Valid {
+            return d.Float64
+        }
+    case *sql.NullBool:
+        if d.Valid {
+            return d.Bool
+        }
+    case *bool:
+        return *d
+    case *sql.NullTime:
+        if d.Valid {
+            return d.Time.Format(time.RFC3339Nano)
+        }
+    case *[]byte:
+        if *d != nil {
+            return *d
+        }
+    case *sql.NullString:
+        if d.Valid {
+            return d.String
+        }
+    case *string:
+        if *d != "" {
+            return *d
+        }
+    }
+    return nil
+}
+
+// outFromText converts an OUT value read as text into the declared type
+func outFromText(typeName string, s sql.NullString) (any, error) {
+    if !s.Valid {
+        return nil, nil
+    }
+    switch typeName {
+    case "int":
+        return strconv.ParseInt(s.String, 10, 64)
+    case "float", "double":
+        return strconv.ParseFloat(s.String, 64)
+    case "bool":
+        switch strings.ToLower(s.String) {
+        case "1", "t", "true":
+            return true, nil
+        case "0", "f", "false":
+            return false, nil
+        }
+        return nil, errors.New("valor booleano no válido: " + s.String)
+    case "blob":
+        return []byte(s.String), nil
+    }
+    return s.String, nil
+}
+
+func procedureResult(sets []STRC.ResultSet, out map[string]any) STRC.InternalResult {
+    if sets == nil {
+        sets = []STRC.ResultSet{}
+    }
+    jsonData, err := json.Marshal(STRC.ProcedureResult{Resultsets: sets, Out: out})
+    if err != nil {
+        return procedureError(err.Error())
+    }
+    return STRC.InternalResult{
+        Json:     string(jsonData),
+        Is_error: 0,
+        Is_empty: 0,
+    }
+}
+
+func procedureError(message string) STRC.InternalResult {
+    return STRC.InternalResult{
+        Json:     createErrorJSON(message),
+        Is_error: 1,
+        Is_empty: 0,
+    }
+}
@@ -0,0 +1,290 @@
+package db
+
+import (
+    "context"
+    "database/sql"
+    "database/sql/driver"
+    "fmt"
+    "strings"
+    "time"
+)
+
+// ReadOnlyDialect is implemented by dialects whose sessions can be made
+// read-only. ReadOnlySession returns the statements run on every new
+// connection of a read-only connector. SQL Server and Oracle have no such
+// session setting and rely on the statement classifier alone.
+type ReadOnlyDialect interface {
+    ReadOnlySession() []string
+}
+
+// RejectedError is returned for a statement the connector does not allow.
+// It is reported with ErrorCodeRejected in the error JSON.
+type RejectedError struct {
+    Reason string
+}
+
+func (e *RejectedError) Error() string {
+    return "sentencia rechazada: " + e.Reason
+}
+
+// SetReadOnly allows only reads on the connector and its replicas. Writes, DDL,
+// procedure calls, session commands and reads that lock rows are rejected
+// before they reach the server; on SQLite, MySQL and PostgreSQL the sessions
+// are also opened read-only, so the connector switches to a new handle and
+// the old one is closed once the statements running on it are done. Queries
+// holding more than one statement are rejected; run scripts through
+// SQLrunScriptOnLoad, which checks every statement.
+func SetReadOnly(connector *Connector, readOnly bool) {
+    connector.mu.Lock()
+    replicas := connector.replicas
+    changed := connector.readOnly != readOnly
+    connector.readOnly = readOnly
+    _, hasSession := connector.dialect.(ReadOnlyDialect)
+    reopen := changed && hasSession && !connector.dead && !connector.closed
+    session := connector.session()
+    connector.mu.Unlock()
+
+    if reopen {
+        connector.reopen(readOnly, session)
+    }
+    for _, replica := range replicas {
+        SetReadOnly(replica, readOnly)
+    }
+}
+
+// reopen switches the connector to a handle opened with session, the one of
+// readOnly. If it cannot be opened the connector is left for handle to reopen.
+func (c *Connector) reopen(readOnly bool, session []string) {
+    // Abrimos fuera del lock: el ping puede requerir un viaje al servidor
+    db, err := openConnection(c.dialect, c.conexion, session...)
+
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    if c.closed || c.dead || c.readOnly != readOnly {
+        // Otra llamada cambió el estado mientras tanto
+        if err == nil {
+            db.Close()
+        }
+        return
+    }
+
+    old := c.db
+    if err != nil {
+        c.dead = true
+    } else {
+        c.settings.apply(db)
+        c.db = db
+    }
+    c.stmts.clear()
+    go retire(old)
+}
+
+// retirePoll is how often retire looks at the connections of a handle
+const retirePoll = 100 * time.Millisecond
+
+// retire closes db once none of its connections is in use, so the statements
+// and transactions that got the handle before it was replaced can finish
+func retire(db *sql.DB) {
+    for db.Stats().InUse > 0 {
+        time.Sleep(retirePoll)
+    }
+    db.Close()
+}
+
+// AllowStatementKinds limits the connector to the given statement kinds and,
+// as SetReadOnly does, to one statement per query. Calling it without kinds
+// lifts the limit.
+func AllowStatementKinds(connector *Connector, kinds ...StatementKind) error {
+    var allowed map[StatementKind]bool
+    for _, kind := range kinds {
+        if _, ok := kindRank[kind]; !ok {
+            return fmt.Errorf("tipo de sentencia no válido: %q", kind)
+        }
+        if allowed == nil {
+            allowed = make(map[StatementKind]bool)
+        }
+        allowed[kind] = true
+    }
+
+    connector.mu.Lock()
+    defer connector.mu.Unlock()
+    connector.allowedKinds = allowed
+    return nil
+}
+
+// AllowTables limits the connector to statements that only name the given
+// tables. An unqualified table allows it under any schema; "schema.table"
+// allows only that one. Procedure calls and statements the classifier does
+// not recognise are rejected while the limit is on, since the tables they
+// reach cannot be told. Calling it without tables lifts the limit.
+func AllowTables(connector *Connector, tables ...string) {
+    var allowed map[string]bool
+    for _, table := range tables {
+        if allowed == nil {
+            allowed = make(map[string]bool)
+        }
+        for _, tokens := range lexStatements(connector.dialect, table).statements {
+            if name, _ := qualifiedName(tokens, 0); name != "" {
+                allowed[name] = true
+            }
+        }
+    }
+
+    connector.mu.Lock()
+    defer connector.mu.Unlock()
+    connector.allowedTables = allowed
+}
+
+// allows checks query against the read-only flag and the allow-lists of the
+// connector. While any of them is on, query must be a single statement that
+// the lexer reads the way the server does: text that ends inside a literal or
+// a comment, or whose literals end where a backslash decides, is rejected, so
+// a second statement cannot hide behind a quote the classifier misread.
+func (c *Connector) allows(query string) error {
+    c.mu.RLock()
+    readOnly, kinds, tables := c.readOnly, c.allowedKinds, c.allowedTables
+    c.mu.RUnlock()
+    if !readOnly && kinds == nil && tables == nil {
+        return nil
+    }
+
+    lexed := lexStatements(c.dialect, query)
+    switch {
+    case lexed.unterminated:
+        return &RejectedError{Reason: "la sentencia termina dentro de un literal, un identificador o un comentario"}
+    case lexed.ambiguous:
+        return &RejectedError{Reason: "una barra invertida antes de una comilla hace ambiguo el final de un literal; doble la comilla"}
+    case len(lexed.statements) > 1:
+        return &RejectedError{Reason: fmt.Sprintf("se permite una sola sentencia por consulta y hay %d", len(lexed.statements))}
+    }
+
+    for _, tokens := range lexed.statements {
+        st := classifyStatement(tokens)
+        switch {
+        case readOnly && st.Kind != StatementRead:
+            return &RejectedError{Reason: fmt.Sprintf("el conector es de solo lectura y la sentencia %s es de tipo %q", st.Verb, st.Kind)}
+        case readOnly && st.Locking:
+            return &RejectedError{Reason: fmt.Sprintf("el conector es de solo lectura y la sentencia %s bloquea filas", st.Verb)}
+        case kinds != nil && !kinds[st.Kind]:
+            return &RejectedError{Reason: fmt.Sprintf("las sentencias de tipo %q no están permitidas", st.Kind)}
+        case tables != nil && (st.Kind == StatementCall || st.Kind == StatementUnknown):
+            return &RejectedError{Reason: fmt.Sprintf("no se pueden comprobar las tablas de la sentencia %s", st.Verb)}
+        }
+        for _, table := range st.Tables {
+            if tables != nil && !tableAllowed(tables, table) {
+                return &RejectedError{Reason: fmt.Sprintf("la tabla %q no está permitida", table)}
+            }
+        }
+    }
+    return nil
+}
+
+func tableAllowed(tables map[string]bool, table string) bool {
+    if tables[table] {
+        return true
+    }
+    return tables[table[strings.LastIndexByte(table, '.')+1:]]
+}
+
+// session returns the statements that open a connection of the connector.
+// It must be called with c.mu held.
+func (c *Connector) session() []string {
+    if rd, ok := c.dialect.(ReadOnlyDialect); ok && c.readOnly {
+        return rd.ReadOnlySession()
+    }
+    return nil
+}
+
+// txOptions makes the transactions of a read-only connector read-only where
+// the driver supports it; go-mssqldb rejects the option
+func (c *Connector) txOptions(level sql.IsolationLevel) *sql.TxOptions {
+    c.mu.RLock()
+    defer c.mu.RUnlock()
+    _, mssql := c.dialect.(sqlserverDialect)
+    return &sql.TxOptions{Isolation: level, ReadOnly: c.readOnly && !mssql}
+}
+
+func (sqliteDialect) ReadOnlySession() []string {
+    return []string{"PRAGMA query_only = ON"}
+}
+
+func (mysqlDialect) ReadOnlySession() []string {
+    return []string{"SET SESSION TRANSACTION READ ONLY"}
+}
+
+func (postgresDialect) ReadOnlySession() []string {
+    return []string{"SET SESSION CHARACTERISTICS AS TRANSACTION READ ONLY"}
+}
+
+// sessionConnector runs the session statements on every connection it opens
+type sessionConnector struct {
+    driver.Connector
+    statements []string
+}
+
+func (s sessionConnector) Connect(ctx context.Context) (driver.Conn, error) {
+    conn, err := s.Connector.Connect(ctx)
+    if err != nil {
+        return nil, err
+    }
+    for _, query := range s.statements {
+        if err := execSession(ctx, conn, query); err != nil {
+            conn.Close()
+            return nil, fmt.Errorf("error al preparar la sesión (%s): %w", query, err)
+        }
+    }
+    return conn, nil
+}
+
+func execSession(ctx context.Context, conn driver.Conn, query string) error {
+    if execer, ok := conn.(driver.ExecerContext); ok {
+        _, err := execer.ExecContext(ctx, query, nil)
+        if err != driver.ErrSkip {
+            return err
+        }
+    }
+
+    stmt, err := conn.Prepare(query)
+    if err != nil {
+        return err
+    }
+    defer stmt.Close()
+    if execer, ok := stmt.(driver.StmtExecContext); ok {
+        _, err = execer.ExecContext(ctx, nil)
+        return err
+    }
+    _, err = stmt.Exec(nil)
+    return err
+}
+
+// dsnConnector opens connections for drivers without driver.DriverContext
+type dsnConnector struct {
+    dsn    string
+    driver driver.Driver
+}
+
+func (c dsnConnector) Connect(context.Context) (driver.Conn, error) {
+    return c.driver.Open(c.dsn)
+}
+
+func (c dsnConnector) Driver() driver.Driver {
+    return c.driver
+}
+
+// openSession opens db through a connector that runs statements on every new connection
+func openSession(d Dialect, conexion string, statements []string) (*sql.DB, error) {
+    probe, err := sql.Open(d.DriverName(), conexion)
+    if err != nil {
+        return nil, err
+    }
+    drv := probe.Driver()
+    probe.Close()
+
+    var base driver.Connector = dsnConnector{dsn: conexion, driver: drv}
+    if dc, ok := drv.(driver.DriverContext); ok {
+        if base, err = dc.OpenConnector(conexion); err != nil {
+            return nil, err
+        }
+    }
+    return sql.OpenDB(sessionConnector{Connector: base, statements: statements}), nil
+}
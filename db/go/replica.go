@@ -0,0 +1,92 @@
+package db
+
+import (
+    "context"
+    "fmt"
+    "time"
+)
+
+// LoadSQLWithReplicas loads the connector for the primary DSN and attaches a
+// connector for each replica DSN. SQLrunonLoad then sends reads to the healthy
+// replicas in turn and everything else to the primary. Calling it again for the
+// same primary replaces its replica set.
+func LoadSQLWithReplicas(driver string, primary string, replicas []string, maxOpenConns, maxIdleConns int, connMaxLifetime, connMaxIdleTime time.Duration) (*Connector, error) {
+    connector, err := LoadSQL(driver, primary, maxOpenConns, maxIdleConns, connMaxLifetime, connMaxIdleTime)
+    if err != nil {
+        return nil, err
+    }
+
+    loaded := make([]*Connector, 0, len(replicas))
+    for i, dsn := range replicas {
+        replica, err := LoadSQL(driver, dsn, maxOpenConns, maxIdleConns, connMaxLifetime, connMaxIdleTime)
+        if err != nil {
+            return nil, fmt.Errorf("Error al conectar a la réplica %d: %w", i+1, err)
+        }
+        loaded = append(loaded, replica)
+    }
+
+    connector.mu.Lock()
+    connector.replicas = loaded
+    connector.mu.Unlock()
+    return connector, nil
+}
+
+// PinPrimary sends every statement run on the connector to the primary while
+// pinned is true, e.g. to read back rows right after writing them
+func PinPrimary(connector *Connector, pinned bool) {
+    connector.mu.Lock()
+    defer connector.mu.Unlock()
+    connector.pinned = pinned
+}
+
+type primaryKey struct{}
+
+// WithPrimary returns a context that pins the statements run with it to the
+// primary. Transactions always run on the primary.
+func WithPrimary(ctx context.Context) context.Context {
+    return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// route picks the connector that runs query: a healthy replica for reads when
+// the connector has replicas and is not pinned, the connector itself otherwise
+func (c *Connector) route(ctx context.Context, query string) *Connector {
+    c.mu.RLock()
+    replicas, pinned := c.replicas, c.pinned
+    c.mu.RUnlock()
+
+    if len(replicas) == 0 || pinned || ctx.Value(primaryKey{}) != nil || !isReadStatement(c.dialect, query) {
+        return c
+    }
+
+    n := uint64(len(replicas))
+    start := c.nextReplica.Add(1)
+    for i := uint64(0); i < n; i++ {
+        if replica := replicas[(start+i)%n]; replica.healthy() {
+            return replica
+        }
+    }
+    return c
+}
+
+// healthy reports whether the connector is open and was not dropped by the health checker
+func (c *Connector) healthy() bool {
+    c.mu.RLock()
+    defer c.mu.RUnlock()
+    return !c.dead && !c.closed
+}
+
+// isReadStatement reports whether every statement of query only reads and
+// may run on a replica. Reads that lock rows, and anything the classifier
+// does not recognise, go to the primary.
+func isReadStatement(d Dialect, query string) bool {
+    if d.IsNonReturning(query) {
+        return false
+    }
+    statements := ClassifyStatements(d, query)
+    for _, st := range statements {
+        if st.Kind != StatementRead || st.Locking {
+            return false
+        }
+    }
+    return len(statements) > 0
+}
@@ -0,0 +1,253 @@
+package db
+
+import (
+    "container/list"
+    "context"
+    "encoding/base64"
+    "fmt"
+    "regexp"
+    "strings"
+    "sync"
+    "time"
+
+    ENCODER "github.com/IngenieroRicardo/db/ENCODER"
+    STRC "github.com/IngenieroRicardo/db/STRUCTURES"
+)
+
+// identifierWord splits a query into the words it is tagged with
+var identifierWord = regexp.MustCompile(`[\w$#]+`)
+
+// DefaultResultCacheBytes bounds the result cache when EnableResultCache is
+// given no positive size
+const DefaultResultCacheBytes = 64 << 20
+
+// resultCache keeps the JSON of SELECT results for a TTL, bounded by the
+// total size of the cached JSON. Entries are tagged with every word of their
+// query, so a write to a table drops the results that mention it.
+type resultCache struct {
+    mu       sync.Mutex
+    ttl      time.Duration
+    maxBytes int64
+    bytes    int64
+    order    *list.List // *cachedResult, most recently used first
+    byKey    map[string]*list.Element
+    hits     int64
+    misses   int64
+    gen      uint64 // invalidation generation: a read stores its result only if no write ended while it ran
+}
+
+type cachedResult struct {
+    key     string
+    result  STRC.InternalResult
+    words   map[string]bool
+    expires time.Time
+    size    int64
+}
+
+type cacheTTLKey struct{}
+
+// EnableResultCache caches the results of the reads run through SQLrunonLoad
+// for ttl, keeping at most maxBytes of JSON, or DefaultResultCacheBytes when
+// maxBytes is zero or less. Calling it again resets the cache.
+func EnableResultCache(connector *Connector, ttl time.Duration, maxBytes int64) {
+    if maxBytes <= 0 {
+        maxBytes = DefaultResultCacheBytes
+    }
+    cache := &resultCache{
+        ttl:      ttl,
+        maxBytes: maxBytes,
+        order:    list.New(),
+        byKey:    make(map[string]*list.Element),
+    }
+
+    connector.mu.Lock()
+    defer connector.mu.Unlock()
+    connector.results = cache
+}
+
+// DisableResultCache drops the connector result cache
+func DisableResultCache(connector *Connector) {
+    connector.mu.Lock()
+    defer connector.mu.Unlock()
+    connector.results = nil
+}
+
+// cachedResults returns the result cache of the connector, nil when it is off
+func (c *Connector) cachedResults() *resultCache {
+    c.mu.RLock()
+    defer c.mu.RUnlock()
+    return c.results
+}
+
+// WithCacheTTL returns a context that caches the results of its reads for ttl
+// instead of the connector default. Zero or less skips the cache.
+func WithCacheTTL(ctx context.Context, ttl time.Duration) context.Context {
+    return context.WithValue(ctx, cacheTTLKey{}, ttl)
+}
+
+// InvalidateResultCache drops the cached results that mention any of tables,
+// or every cached result when no table is given
+func InvalidateResultCache(connector *Connector, tables ...string) {
+    connector.cachedResults().invalidate(tables...)
+}
+
+// ResultCacheStats reports the size and the hit/miss counters of the
+// connector result cache
+func ResultCacheStats(connector *Connector) STRC.ResultCacheStats {
+    c := connector.cachedResults()
+    if c == nil {
+        return STRC.ResultCacheStats{}
+    }
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    return STRC.ResultCacheStats{
+        Enabled:  true,
+        Entries:  c.order.Len(),
+        Bytes:    c.bytes,
+        MaxBytes: c.maxBytes,
+        Hits:     c.hits,
+        Misses:   c.misses,
+    }
+}
+
+// resultKey identifies a read by its text, its arguments and the JSON shape asked for
+func resultKey(ctx context.Context, mode ENCODER.Mode, query string, args []any) string {
+    var sb strings.Builder
+    fmt.Fprintf(&sb, "%d|%t|%s", mode, envelopeRequested(ctx), query)
+    for _, arg := range args {
+        switch v := arg.(type) {
+        case []byte:
+            fmt.Fprintf(&sb, "\x00[]byte:%s", base64.StdEncoding.EncodeToString(v))
+        case time.Time:
+            fmt.Fprintf(&sb, "\x00time:%s", v.Format(time.RFC3339Nano))
+        default:
+            fmt.Fprintf(&sb, "\x00%T:%v", v, v)
+        }
+    }
+    return sb.String()
+}
+
+func (c *resultCache) get(key string) (STRC.InternalResult, bool) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    e, ok := c.byKey[key]
+    if !ok {
+        c.misses++
+        return STRC.InternalResult{}, false
+    }
+    entry := e.Value.(*cachedResult)
+    if time.Now().After(entry.expires) {
+        c.remove(e)
+        c.misses++
+        return STRC.InternalResult{}, false
+    }
+    c.hits++
+    c.order.MoveToFront(e)
+    return entry.result, true
+}
+
+// currentGeneration is read before a query runs and handed to put with its result
+func (c *resultCache) currentGeneration() uint64 {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    return c.gen
+}
+
+// put stores result for the TTL of ctx or the cache default. The result is
+// dropped when the cache was invalidated since generation was read.
+func (c *resultCache) put(ctx context.Context, key string, query string, result STRC.InternalResult, generation uint64) {
+    ttl := c.ttl
+    if v, ok := ctx.Value(cacheTTLKey{}).(time.Duration); ok {
+        ttl = v
+    }
+    size := int64(len(key) + len(result.Json))
+    if ttl <= 0 || size > c.maxBytes {
+        return
+    }
+
+    words := make(map[string]bool)
+    for _, word := range identifierWord.FindAllString(strings.ToLower(query), -1) {
+        words[word] = true
+    }
+
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    if generation != c.gen {
+        return
+    }
+    if e, ok := c.byKey[key]; ok {
+        c.remove(e)
+    }
+    entry := &cachedResult{key: key, result: result, words: words, expires: time.Now().Add(ttl), size: size}
+    c.byKey[key] = c.order.PushFront(entry)
+    c.bytes += size
+    for c.bytes > c.maxBytes {
+        c.remove(c.order.Back())
+    }
+}
+
+// invalidate drops the entries that mention any of tables, or all of them
+func (c *resultCache) invalidate(tables ...string) {
+    if c == nil {
+        return
+    }
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    c.gen++
+
+    if len(tables) == 0 {
+        c.order.Init()
+        c.byKey = make(map[string]*list.Element)
+        c.bytes = 0
+        return
+    }
+
+    names := make([]string, len(tables))
+    for i, table := range tables {
+        _, names[i] = splitTableName(GenericDialect{}, table)
+        names[i] = strings.ToLower(names[i])
+    }
+    for e := c.order.Front(); e != nil; {
+        next := e.Next()
+        entry := e.Value.(*cachedResult)
+        for _, name := range names {
+            if entry.words[name] {
+                c.remove(e)
+                break
+            }
+        }
+        e = next
+    }
+}
+
+// invalidateWrite drops the results the statements of query may have
+// changed: those naming a table of any write or DDL statement. When a
+// statement names no table, or is a call or anything else the classifier
+// cannot tie to tables, the whole cache is dropped.
+func (c *resultCache) invalidateWrite(d Dialect, query string) {
+    if c == nil {
+        return
+    }
+    var tables []string
+    for _, st := range ClassifyStatements(d, query) {
+        switch {
+        case st.Kind == StatementRead:
+            continue
+        case (st.Kind == StatementWrite || st.Kind == StatementDDL) && len(st.Tables) > 0:
+            tables = append(tables, st.Tables...)
+        default:
+            c.invalidate()
+            return
+        }
+    }
+    if len(tables) > 0 {
+        c.invalidate(tables...)
+    }
+}
+
+// remove must be called with c.mu held
+func (c *resultCache) remove(e *list.Element) {
+    entry := c.order.Remove(e).(*cachedResult)
+    delete(c.byKey, entry.key)
+    c.bytes -= entry.size
+}
@@ -0,0 +1,123 @@
+package db
+
+import (
+    "context"
+    "sort"
+    "strings"
+    "testing"
+    "time"
+
+    STRC "github.com/IngenieroRicardo/db/STRUCTURES"
+)
+
+func newTestResultCache(t *testing.T, ttl time.Duration, maxBytes int64) *resultCache {
+    t.Helper()
+    connector := &Connector{}
+    EnableResultCache(connector, ttl, maxBytes)
+    return connector.cachedResults()
+}
+
+// putQuery caches a result under the query text itself
+func putQuery(c *resultCache, query string, json string) {
+    c.put(context.Background(), query, query, STRC.InternalResult{Json: json}, c.currentGeneration())
+}
+
+// cachedKeys lists the keys of c, sorted
+func cachedKeys(c *resultCache) []string {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    var keys []string
+    for key := range c.byKey {
+        keys = append(keys, key)
+    }
+    sort.Strings(keys)
+    return keys
+}
+
+func TestResultCacheDefaultSize(t *testing.T) {
+    for _, maxBytes := range []int64{0, -1} {
+        if c := newTestResultCache(t, time.Minute, maxBytes); c.maxBytes != DefaultResultCacheBytes {
+            t.Errorf("EnableResultCache(%d) kept %d bytes, want %d", maxBytes, c.maxBytes, DefaultResultCacheBytes)
+        }
+    }
+}
+
+func TestResultCacheLRU(t *testing.T) {
+    // Cada entrada ocupa la consulta más el JSON: 8 + 2 bytes
+    c := newTestResultCache(t, time.Minute, 30)
+    putQuery(c, "SELECT 1", "[]")
+    putQuery(c, "SELECT 2", "[]")
+    putQuery(c, "SELECT 3", "[]")
+    if _, ok := c.get("SELECT 1"); !ok {
+        t.Fatal("SELECT 1 missing")
+    }
+    putQuery(c, "SELECT 4", "[]") // evicts SELECT 2, the least recently used
+
+    want := []string{"SELECT 1", "SELECT 3", "SELECT 4"}
+    if got := cachedKeys(c); strings.Join(got, ",") != strings.Join(want, ",") {
+        t.Errorf("cached %q, want %q", got, want)
+    }
+    if c.bytes != 30 {
+        t.Errorf("bytes = %d, want 30", c.bytes)
+    }
+
+    putQuery(c, "SELECT 5", strings.Repeat("x", 30))
+    if _, ok := c.get("SELECT 5"); ok {
+        t.Error("a result larger than the cache was stored")
+    }
+}
+
+func TestResultCacheTTL(t *testing.T) {
+    c := newTestResultCache(t, time.Minute, 0)
+    ctx := WithCacheTTL(context.Background(), time.Millisecond)
+    c.put(ctx, "SELECT 1", "SELECT 1", STRC.InternalResult{Json: "[]"}, c.currentGeneration())
+    time.Sleep(5 * time.Millisecond)
+    if _, ok := c.get("SELECT 1"); ok {
+        t.Error("an expired result was returned")
+    }
+
+    c.put(WithCacheTTL(context.Background(), 0), "SELECT 2", "SELECT 2", STRC.InternalResult{Json: "[]"}, c.currentGeneration())
+    if _, ok := c.get("SELECT 2"); ok {
+        t.Error("a result was cached with a zero TTL")
+    }
+}
+
+func TestResultCacheStalePut(t *testing.T) {
+    c := newTestResultCache(t, time.Minute, 0)
+    generation := c.currentGeneration()
+    // Una escritura termina mientras la lectura sigue en curso
+    c.invalidate("t")
+    c.put(context.Background(), "SELECT * FROM t", "SELECT * FROM t", STRC.InternalResult{Json: "[]"}, generation)
+    if _, ok := c.get("SELECT * FROM t"); ok {
+        t.Error("a result read before a write was cached after it")
+    }
+}
+
+func TestResultCacheInvalidateWrite(t *testing.T) {
+    tests := []struct {
+        name  string
+        query string
+        want  []string
+    }{
+        {"update", "UPDATE a SET x = 1", []string{"SELECT * FROM b", "SELECT * FROM s.c"}},
+        {"every write of a batch", "INSERT INTO a VALUES (1); DELETE FROM b", []string{"SELECT * FROM s.c"}},
+        {"write after a read", "SELECT 1; UPDATE s.c SET x = 1", []string{"SELECT * FROM a", "SELECT * FROM b"}},
+        {"ddl", "DROP TABLE b", []string{"SELECT * FROM a", "SELECT * FROM s.c"}},
+        {"read", "SELECT * FROM a", []string{"SELECT * FROM a", "SELECT * FROM b", "SELECT * FROM s.c"}},
+        {"call", "CALL p()", nil},
+        {"write without a table", "INSERT INTO a VALUES (1); SET x = 1", nil},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            c := newTestResultCache(t, time.Minute, 0)
+            for _, query := range []string{"SELECT * FROM a", "SELECT * FROM b", "SELECT * FROM s.c"} {
+                putQuery(c, query, "[]")
+            }
+            c.invalidateWrite(dialectFor("postgres"), tt.query)
+            if got := cachedKeys(c); strings.Join(got, ",") != strings.Join(tt.want, ",") {
+                t.Errorf("invalidateWrite(%q) kept %q, want %q", tt.query, got, tt.want)
+            }
+        })
+    }
+}
@@ -0,0 +1,529 @@
+package db
+
+import (
+    "context"
+    "database/sql"
+    "encoding/json"
+    "fmt"
+    "strconv"
+    "strings"
+
+    STRC "github.com/IngenieroRicardo/db/STRUCTURES"
+)
+
+// SchemaDialect is implemented by dialects that can describe their catalog.
+// Table names arrive already split and case-folded; schema is nil when the
+// caller did not qualify the table, meaning the current schema. Every query
+// returns the columns listed for it, in that order.
+type SchemaDialect interface {
+    // ListTablesSQL: schema, name, type ('table' or 'view')
+    ListTablesSQL() string
+    // DescribeTableSQL: name, type, nullable (0/1), default, primary_key (0/1), position
+    DescribeTableSQL(schema any, table string) (string, []any)
+    // ListIndexesSQL: index name, column, unique (0/1), primary (0/1); one row per column in key order
+    ListIndexesSQL(schema any, table string) (string, []any)
+    // ListForeignKeysSQL: id, name, column, referenced table, referenced column,
+    // on update, on delete; one row per column in key order
+    ListForeignKeysSQL(schema any, table string) (string, []any)
+    // ListProceduresSQL: schema, name, type ('procedure' or 'function'); "" when the engine has none
+    ListProceduresSQL() string
+}
+
+// ListTables returns the tables and views of the current schema as
+// [{"schema","name","type"}]
+func ListTables(connector *Connector) STRC.InternalResult {
+    sd, result, ok := schemaDialect(connector)
+    if !ok {
+        return result
+    }
+
+    rows, result, ok := catalogRows(connector, sd.ListTablesSQL(), nil, 3)
+    if !ok {
+        return result
+    }
+    tables := make([]STRC.TableInfo, 0, len(rows))
+    for _, r := range rows {
+        tables = append(tables, STRC.TableInfo{Schema: r[0].String, Name: r[1].String, Type: r[2].String})
+    }
+    return catalogResult(tables, len(tables))
+}
+
+// DescribeTable returns the columns of table ("table" or "schema.table") as
+// [{"name","type","nullable","default","primary_key","position"}]
+func DescribeTable(connector *Connector, table string) STRC.InternalResult {
+    sd, result, ok := schemaDialect(connector)
+    if !ok {
+        return result
+    }
+
+    query, args := sd.DescribeTableSQL(splitTableName(connector.dialect, table))
+    rows, result, ok := catalogRows(connector, query, args, 6)
+    if !ok {
+        return result
+    }
+    columns := make([]STRC.ColumnInfo, 0, len(rows))
+    for _, r := range rows {
+        column := STRC.ColumnInfo{
+            Name:       r[0].String,
+            Type:       r[1].String,
+            Nullable:   catalogBool(r[2]),
+            PrimaryKey: catalogBool(r[4]),
+        }
+        if r[3].Valid {
+            column.Default = &r[3].String
+        }
+        column.Position, _ = strconv.Atoi(r[5].String)
+        columns = append(columns, column)
+    }
+    return catalogResult(columns, len(columns))
+}
+
+// ListIndexes returns the indexes of table as [{"name","columns","unique","primary"}]
+func ListIndexes(connector *Connector, table string) STRC.InternalResult {
+    sd, result, ok := schemaDialect(connector)
+    if !ok {
+        return result
+    }
+
+    query, args := sd.ListIndexesSQL(splitTableName(connector.dialect, table))
+    rows, result, ok := catalogRows(connector, query, args, 4)
+    if !ok {
+        return result
+    }
+    indexes := make([]STRC.IndexInfo, 0)
+    for _, r := range rows {
+        // Las filas llegan ordenadas por índice: agrupamos sus columnas
+        if n := len(indexes); n > 0 && indexes[n-1].Name == r[0].String {
+            indexes[n-1].Columns = append(indexes[n-1].Columns, r[1].String)
+            continue
+        }
+        indexes = append(indexes, STRC.IndexInfo{
+            Name:    r[0].String,
+            Columns: []string{r[1].String},
+            Unique:  catalogBool(r[2]),
+            Primary: catalogBool(r[3]),
+        })
+    }
+    return catalogResult(indexes, len(indexes))
+}
+
+// ListForeignKeys returns the foreign keys of table as
+// [{"name","columns","referenced_table","referenced_columns","on_update","on_delete"}]
+func ListForeignKeys(connector *Connector, table string) STRC.InternalResult {
+    sd, result, ok := schemaDialect(connector)
+    if !ok {
+        return result
+    }
+
+    query, args := sd.ListForeignKeysSQL(splitTableName(connector.dialect, table))
+    rows, result, ok := catalogRows(connector, query, args, 7)
+    if !ok {
+        return result
+    }
+    keys := make([]STRC.ForeignKeyInfo, 0)
+    lastID := ""
+    for _, r := range rows {
+        if n := len(keys); n > 0 && lastID == r[0].String {
+            keys[n-1].Columns = append(keys[n-1].Columns, r[2].String)
+            keys[n-1].ReferencedColumns = append(keys[n-1].ReferencedColumns, r[4].String)
+            continue
+        }
+        lastID = r[0].String
+        keys = append(keys, STRC.ForeignKeyInfo{
+            Name:              r[1].String,
+            Columns:           []string{r[2].String},
+            ReferencedTable:   r[3].String,
+            ReferencedColumns: []string{r[4].String},
+            OnUpdate:          referentialAction(r[5].String),
+            OnDelete:          referentialAction(r[6].String),
+        })
+    }
+    return catalogResult(keys, len(keys))
+}
+
+// ListProcedures returns the stored procedures and functions of the current
+// schema as [{"schema","name","type"}]
+func ListProcedures(connector *Connector) STRC.InternalResult {
+    sd, result, ok := schemaDialect(connector)
+    if !ok {
+        return result
+    }
+
+    procedures := make([]STRC.ProcedureInfo, 0)
+    if query := sd.ListProceduresSQL(); query != "" {
+        rows, result, ok := catalogRows(connector, query, nil, 3)
+        if !ok {
+            return result
+        }
+        for _, r := range rows {
+            procedures = append(procedures, STRC.ProcedureInfo{Schema: r[0].String, Name: r[1].String, Type: r[2].String})
+        }
+    }
+    return catalogResult(procedures, len(procedures))
+}
+
+func schemaDialect(connector *Connector) (SchemaDialect, STRC.InternalResult, bool) {
+    sd, ok := connector.dialect.(SchemaDialect)
+    if !ok {
+        return nil, STRC.InternalResult{
+            Json:     createErrorJSON(fmt.Sprintf("El driver %s no soporta introspección", connector.driver)),
+            Is_error: 1,
+            Is_empty: 0,
+        }, false
+    }
+    return sd, STRC.InternalResult{}, true
+}
+
+// catalogRows runs a catalog query returning width columns and reads them as strings
+func catalogRows(connector *Connector, query string, args []any, width int) ([][]sql.NullString, STRC.InternalResult, bool) {
+    ctx, cancel := statementContext(context.Background(), connector)
+    defer cancel()
+
+    db, err := connector.handle()
+    if err != nil {
+        return nil, errorResult(ctx, connector.dialect, "", err), false
+    }
+
+    rows, err := db.QueryContext(ctx, query, args...)
+    if err != nil {
+        return nil, errorResult(ctx, connector.dialect, "Error al consultar el catálogo", err), false
+    }
+    defer rows.Close()
+
+    var result [][]sql.NullString
+    for rows.Next() {
+        row := make([]sql.NullString, width)
+        dest := make([]any, width)
+        for i := range row {
+            dest[i] = &row[i]
+        }
+        if err := rows.Scan(dest...); err != nil {
+            return nil, errorResult(ctx, connector.dialect, "Error al escanear fila", err), false
+        }
+        result = append(result, row)
+    }
+    if err := rows.Err(); err != nil {
+        return nil, errorResult(ctx, connector.dialect, "Error después de iterar filas", err), false
+    }
+    return result, STRC.InternalResult{}, true
+}
+
+func catalogResult(v any, count int) STRC.InternalResult {
+    jsonData, err := json.Marshal(v)
+    if err != nil {
+        return STRC.InternalResult{
+            Json:     createErrorJSON(err.Error()),
+            Is_error: 1,
+            Is_empty: 0,
+        }
+    }
+    return STRC.InternalResult{
+        Json:     string(jsonData),
+        Is_error: 0,
+        Is_empty: boolToInt(count == 0),
+    }
+}
+
+// splitTableName separates "schema.table" and folds unquoted parts the way
+// the engine stores them: lower case on PostgreSQL, upper case on Oracle
+func splitTableName(d Dialect, name string) (any, string) {
+    var parts []string
+    var current strings.Builder
+    quoted := false
+    folded := func(part string, wasQuoted bool) string {
+        if wasQuoted {
+            return part
+        }
+        switch d.(type) {
+        case postgresDialect:
+            return strings.ToLower(part)
+        case oracleDialect:
+            return strings.ToUpper(part)
+        }
+        return part
+    }
+
+    partQuoted := false
+    for i := 0; i < len(name); i++ {
+        c := name[i]
+        switch {
+        case c == '"' || c == '`':
+            quoted = !quoted
+            partQuoted = true
+        case c == '[':
+            quoted = true
+            partQuoted = true
+        case c == ']':
+            quoted = false
+        case c == '.' && !quoted:
+            parts = append(parts, folded(current.String(), partQuoted))
+            current.Reset()
+            partQuoted = false
+        default:
+            current.WriteByte(c)
+        }
+    }
+    parts = append(parts, folded(current.String(), partQuoted))
+
+    if len(parts) == 1 {
+        return nil, parts[0]
+    }
+    return parts[len(parts)-2], parts[len(parts)-1]
+}
+
+func catalogBool(s sql.NullString) bool {
+    switch strings.ToLower(s.String) {
+    case "1", "t", "true", "y", "yes":
+        return true
+    }
+    return false
+}
+
+// referentialAction normalizes NO_ACTION / no action / CASCADE to upper case words
+func referentialAction(action string) string {
+    return strings.ToUpper(strings.ReplaceAll(action, "_", " "))
+}
+
+func boolToInt(b bool) int {
+    if b {
+        return 1
+    }
+    return 0
+}
+
+// SQLite: sqlite_master and the pragma_* table-valued functions
+func (sqliteDialect) ListTablesSQL() string {
+    return `SELECT 'main', name, type FROM sqlite_master
+WHERE type IN ('table', 'view') AND name NOT LIKE 'sqlite_%'
+ORDER BY name`
+}
+
+func (sqliteDialect) DescribeTableSQL(schema any, table string) (string, []any) {
+    return `SELECT name, type, CASE WHEN "notnull" = 1 THEN 0 ELSE 1 END, dflt_value,
+CASE WHEN pk > 0 THEN 1 ELSE 0 END, cid + 1
+FROM pragma_table_info(?2, COALESCE(?1, 'main'))
+ORDER BY cid`, []any{schema, table}
+}
+
+func (sqliteDialect) ListIndexesSQL(schema any, table string) (string, []any) {
+    return `SELECT il.name, ii.name, il."unique", CASE WHEN il.origin = 'pk' THEN 1 ELSE 0 END
+FROM pragma_index_list(?2, COALESCE(?1, 'main')) il
+JOIN pragma_index_info(il.name, COALESCE(?1, 'main')) ii
+ORDER BY il.name, ii.seqno`, []any{schema, table}
+}
+
+func (sqliteDialect) ListForeignKeysSQL(schema any, table string) (string, []any) {
+    // SQLite does not name foreign keys: the id groups their columns
+    return `SELECT id, '', "from", "table", "to", on_update, on_delete
+FROM pragma_foreign_key_list(?2, COALESCE(?1, 'main'))
+ORDER BY id, seq`, []any{schema, table}
+}
+
+func (sqliteDialect) ListProceduresSQL() string {
+    return ""
+}
+
+// MySQL: information_schema, DATABASE() as the current schema
+func (mysqlDialect) ListTablesSQL() string {
+    return `SELECT table_schema, table_name, CASE table_type WHEN 'VIEW' THEN 'view' ELSE 'table' END
+FROM information_schema.tables
+WHERE table_schema = DATABASE()
+ORDER BY table_name`
+}
+
+func (mysqlDialect) DescribeTableSQL(schema any, table string) (string, []any) {
+    return `SELECT column_name, column_type, CASE is_nullable WHEN 'YES' THEN 1 ELSE 0 END, column_default,
+CASE column_key WHEN 'PRI' THEN 1 ELSE 0 END, ordinal_position
+FROM information_schema.columns
+WHERE table_schema = COALESCE(?, DATABASE()) AND table_name = ?
+ORDER BY ordinal_position`, []any{schema, table}
+}
+
+func (mysqlDialect) ListIndexesSQL(schema any, table string) (string, []any) {
+    return `SELECT index_name, column_name, CASE non_unique WHEN 0 THEN 1 ELSE 0 END,
+CASE index_name WHEN 'PRIMARY' THEN 1 ELSE 0 END
+FROM information_schema.statistics
+WHERE table_schema = COALESCE(?, DATABASE()) AND table_name = ?
+ORDER BY index_name, seq_in_index`, []any{schema, table}
+}
+
+func (mysqlDialect) ListForeignKeysSQL(schema any, table string) (string, []any) {
+    return `SELECT k.constraint_name, k.constraint_name, k.column_name, k.referenced_table_name,
+k.referenced_column_name, r.update_rule, r.delete_rule
+FROM information_schema.key_column_usage k
+JOIN information_schema.referential_constraints r
+  ON r.constraint_schema = k.constraint_schema AND r.constraint_name = k.constraint_name AND r.table_name = k.table_name
+WHERE k.table_schema = COALESCE(?, DATABASE()) AND k.table_name = ? AND k.referenced_table_name IS NOT NULL
+ORDER BY k.constraint_name, k.ordinal_position`, []any{schema, table}
+}
+
+func (mysqlDialect) ListProceduresSQL() string {
+    return `SELECT routine_schema, routine_name, LOWER(routine_type)
+FROM information_schema.routines
+WHERE routine_schema = DATABASE()
+ORDER BY routine_name`
+}
+
+// PostgreSQL: information_schema for tables and columns, pg_catalog for keys,
+// current_schema() as the current schema
+func (postgresDialect) ListTablesSQL() string {
+    return `SELECT table_schema, table_name, CASE table_type WHEN 'VIEW' THEN 'view' ELSE 'table' END
+FROM information_schema.tables
+WHERE table_schema = current_schema()
+ORDER BY table_name`
+}
+
+func (postgresDialect) DescribeTableSQL(schema any, table string) (string, []any) {
+    return `SELECT c.column_name, c.data_type, CASE c.is_nullable WHEN 'YES' THEN 1 ELSE 0 END, c.column_default,
+CASE WHEN EXISTS (
+  SELECT 1 FROM information_schema.table_constraints tc
+  JOIN information_schema.key_column_usage k
+    ON k.constraint_schema = tc.constraint_schema AND k.constraint_name = tc.constraint_name
+  WHERE tc.constraint_type = 'PRIMARY KEY' AND tc.table_schema = c.table_schema
+    AND tc.table_name = c.table_name AND k.column_name = c.column_name
+) THEN 1 ELSE 0 END, c.ordinal_position
+FROM information_schema.columns c
+WHERE c.table_schema = COALESCE($1::text, current_schema()) AND c.table_name = $2
+ORDER BY c.ordinal_position`, []any{schema, table}
+}
+
+func (postgresDialect) ListIndexesSQL(schema any, table string) (string, []any) {
+    return `SELECT i.relname, a.attname, CASE WHEN ix.indisunique THEN 1 ELSE 0 END,
+CASE WHEN ix.indisprimary THEN 1 ELSE 0 END
+FROM pg_index ix
+JOIN pg_class t ON t.oid = ix.indrelid
+JOIN pg_class i ON i.oid = ix.indexrelid
+JOIN pg_namespace n ON n.oid = t.relnamespace
+JOIN LATERAL unnest(ix.indkey) WITH ORDINALITY AS k(attnum, ord) ON true
+JOIN pg_attribute a ON a.attrelid = t.oid AND a.attnum = k.attnum
+WHERE n.nspname = COALESCE($1::text, current_schema()) AND t.relname = $2
+ORDER BY i.relname, k.ord`, []any{schema, table}
+}
+
+func (postgresDialect) ListForeignKeysSQL(schema any, table string) (string, []any) {
+    return `SELECT c.conname, c.conname, a.attname, rt.relname, ra.attname,
+CASE c.confupdtype WHEN 'r' THEN 'RESTRICT' WHEN 'c' THEN 'CASCADE' WHEN 'n' THEN 'SET NULL' WHEN 'd' THEN 'SET DEFAULT' ELSE 'NO ACTION' END,
+CASE c.confdeltype WHEN 'r' THEN 'RESTRICT' WHEN 'c' THEN 'CASCADE' WHEN 'n' THEN 'SET NULL' WHEN 'd' THEN 'SET DEFAULT' ELSE 'NO ACTION' END
+FROM pg_constraint c
+JOIN pg_class t ON t.oid = c.conrelid
+JOIN pg_namespace n ON n.oid = t.relnamespace
+JOIN pg_class rt ON rt.oid = c.confrelid
+JOIN LATERAL unnest(c.conkey, c.confkey) WITH ORDINALITY AS k(attnum, refnum, ord) ON true
+JOIN pg_attribute a ON a.attrelid = c.conrelid AND a.attnum = k.attnum
+JOIN pg_attribute ra ON ra.attrelid = c.confrelid AND ra.attnum = k.refnum
+WHERE c.contype = 'f' AND n.nspname = COALESCE($1::text, current_schema()) AND t.relname = $2
+ORDER BY c.conname, k.ord`, []any{schema, table}
+}
+
+func (postgresDialect) ListProceduresSQL() string {
+    return `SELECT routine_schema, routine_name, LOWER(routine_type)
+FROM information_schema.routines
+WHERE routine_schema = current_schema()
+ORDER BY routine_name`
+}
+
+// SQL Server: INFORMATION_SCHEMA for tables and columns, sys catalog views for
+// keys, SCHEMA_NAME() as the current schema
+func (sqlserverDialect) ListTablesSQL() string {
+    return `SELECT TABLE_SCHEMA, TABLE_NAME, CASE TABLE_TYPE WHEN 'VIEW' THEN 'view' ELSE 'table' END
+FROM INFORMATION_SCHEMA.TABLES
+WHERE TABLE_SCHEMA = SCHEMA_NAME()
+ORDER BY TABLE_NAME`
+}
+
+func (sqlserverDialect) DescribeTableSQL(schema any, table string) (string, []any) {
+    return `SELECT c.COLUMN_NAME, c.DATA_TYPE, CASE c.IS_NULLABLE WHEN 'YES' THEN 1 ELSE 0 END, c.COLUMN_DEFAULT,
+CASE WHEN EXISTS (
+  SELECT 1 FROM INFORMATION_SCHEMA.TABLE_CONSTRAINTS tc
+  JOIN INFORMATION_SCHEMA.KEY_COLUMN_USAGE k
+    ON k.CONSTRAINT_SCHEMA = tc.CONSTRAINT_SCHEMA AND k.CONSTRAINT_NAME = tc.CONSTRAINT_NAME
+  WHERE tc.CONSTRAINT_TYPE = 'PRIMARY KEY' AND tc.TABLE_SCHEMA = c.TABLE_SCHEMA
+    AND tc.TABLE_NAME = c.TABLE_NAME AND k.COLUMN_NAME = c.COLUMN_NAME
+) THEN 1 ELSE 0 END, c.ORDINAL_POSITION
+FROM INFORMATION_SCHEMA.COLUMNS c
+WHERE c.TABLE_SCHEMA = COALESCE(@p1, SCHEMA_NAME()) AND c.TABLE_NAME = @p2
+ORDER BY c.ORDINAL_POSITION`, []any{schema, table}
+}
+
+func (sqlserverDialect) ListIndexesSQL(schema any, table string) (string, []any) {
+    return `SELECT i.name, c.name, CAST(i.is_unique AS int), CAST(i.is_primary_key AS int)
+FROM sys.indexes i
+JOIN sys.index_columns ic ON ic.object_id = i.object_id AND ic.index_id = i.index_id
+JOIN sys.columns c ON c.object_id = ic.object_id AND c.column_id = ic.column_id
+JOIN sys.tables t ON t.object_id = i.object_id
+WHERE SCHEMA_NAME(t.schema_id) = COALESCE(@p1, SCHEMA_NAME()) AND t.name = @p2 AND ic.is_included_column = 0
+ORDER BY i.name, ic.key_ordinal`, []any{schema, table}
+}
+
+func (sqlserverDialect) ListForeignKeysSQL(schema any, table string) (string, []any) {
+    return `SELECT fk.name, fk.name, pc.name, rt.name, rc.name,
+fk.update_referential_action_desc, fk.delete_referential_action_desc
+FROM sys.foreign_keys fk
+JOIN sys.foreign_key_columns fkc ON fkc.constraint_object_id = fk.object_id
+JOIN sys.tables t ON t.object_id = fk.parent_object_id
+JOIN sys.columns pc ON pc.object_id = fkc.parent_object_id AND pc.column_id = fkc.parent_column_id
+JOIN sys.tables rt ON rt.object_id = fk.referenced_object_id
+JOIN sys.columns rc ON rc.object_id = fkc.referenced_object_id AND rc.column_id = fkc.referenced_column_id
+WHERE SCHEMA_NAME(t.schema_id) = COALESCE(@p1, SCHEMA_NAME()) AND t.name = @p2
+ORDER BY fk.name, fkc.constraint_column_id`, []any{schema, table}
+}
+
+func (sqlserverDialect) ListProceduresSQL() string {
+    return `SELECT ROUTINE_SCHEMA, ROUTINE_NAME, LOWER(ROUTINE_TYPE)
+FROM INFORMATION_SCHEMA.ROUTINES
+WHERE ROUTINE_SCHEMA = SCHEMA_NAME()
+ORDER BY ROUTINE_NAME`
+}
+
+// Oracle: ALL_* dictionary views, the session CURRENT_SCHEMA as the current schema
+func (oracleDialect) ListTablesSQL() string {
+    return `SELECT owner, table_name, 'table' FROM all_tables
+WHERE owner = SYS_CONTEXT('USERENV', 'CURRENT_SCHEMA')
+UNION ALL
+SELECT owner, view_name, 'view' FROM all_views
+WHERE owner = SYS_CONTEXT('USERENV', 'CURRENT_SCHEMA')
+ORDER BY 2`
+}
+
+func (oracleDialect) DescribeTableSQL(schema any, table string) (string, []any) {
+    return `SELECT c.column_name, c.data_type, CASE c.nullable WHEN 'Y' THEN 1 ELSE 0 END, c.data_default,
+CASE WHEN EXISTS (
+  SELECT 1 FROM all_constraints k
+  JOIN all_cons_columns kc ON kc.owner = k.owner AND kc.constraint_name = k.constraint_name
+  WHERE k.constraint_type = 'P' AND k.owner = c.owner AND k.table_name = c.table_name AND kc.column_name = c.column_name
+) THEN 1 ELSE 0 END, c.column_id
+FROM all_tab_columns c
+WHERE c.owner = COALESCE(:1, SYS_CONTEXT('USERENV', 'CURRENT_SCHEMA')) AND c.table_name = :2
+ORDER BY c.column_id`, []any{schema, table}
+}
+
+func (oracleDialect) ListIndexesSQL(schema any, table string) (string, []any) {
+    return `SELECT i.index_name, ic.column_name, CASE i.uniqueness WHEN 'UNIQUE' THEN 1 ELSE 0 END,
+CASE WHEN EXISTS (
+  SELECT 1 FROM all_constraints k
+  WHERE k.constraint_type = 'P' AND k.owner = i.table_owner AND k.index_name = i.index_name
+) THEN 1 ELSE 0 END
+FROM all_indexes i
+JOIN all_ind_columns ic ON ic.index_owner = i.owner AND ic.index_name = i.index_name
+WHERE i.table_owner = COALESCE(:1, SYS_CONTEXT('USERENV', 'CURRENT_SCHEMA')) AND i.table_name = :2
+ORDER BY i.index_name, ic.column_position`, []any{schema, table}
+}
+
+func (oracleDialect) ListForeignKeysSQL(schema any, table string) (string, []any) {
+    // Oracle has no ON UPDATE actions
+    return `SELECT c.constraint_name, c.constraint_name, cc.column_name, rc.table_name, rcc.column_name,
+'NO ACTION', c.delete_rule
+FROM all_constraints c
+JOIN all_cons_columns cc ON cc.owner = c.owner AND cc.constraint_name = c.constraint_name
+JOIN all_constraints rc ON rc.owner = c.r_owner AND rc.constraint_name = c.r_constraint_name
+JOIN all_cons_columns rcc ON rcc.owner = rc.owner AND rcc.constraint_name = rc.constraint_name AND rcc.position = cc.position
+WHERE c.constraint_type = 'R' AND c.owner = COALESCE(:1, SYS_CONTEXT('USERENV', 'CURRENT_SCHEMA')) AND c.table_name = :2
+ORDER BY c.constraint_name, cc.position`, []any{schema, table}
+}
+
+func (oracleDialect) ListProceduresSQL() string {
+    return `SELECT owner, object_name, LOWER(object_type)
+FROM all_objects
+WHERE owner = SYS_CONTEXT('USERENV', 'CURRENT_SCHEMA') AND object_type IN ('PROCEDURE', 'FUNCTION')
+ORDER BY object_name`
+}
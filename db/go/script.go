@@ -0,0 +1,411 @@
+package db
+
+import (
+    "bytes"
+    "context"
+    "database/sql"
+    "encoding/json"
+    "fmt"
+    "regexp"
+    "strconv"
+    "strings"
+    "time"
+
+    ENCODER "github.com/IngenieroRicardo/db/ENCODER"
+    STRC "github.com/IngenieroRicardo/db/STRUCTURES"
+)
+
+var (
+    // goBatch is the sqlcmd batch separator, optionally repeating the batch
+    goBatch = regexp.MustCompile(`(?i)^[ \t]*GO(?:[ \t]+(\d+))?[ \t]*(?:--.*)?\r?$`)
+    // slashLine runs the PL/SQL block before it in SQL*Plus
+    slashLine = regexp.MustCompile(`^[ \t]*/[ \t]*\r?$`)
+    // delimiterLine is the MySQL client command that changes the statement delimiter
+    delimiterLine = regexp.MustCompile(`(?i)^[ \t]*DELIMITER[ \t]+(\S+)[ \t]*\r?$`)
+)
+
+// routineWords name the objects whose CREATE statement holds a procedural body
+var routineWords = map[string]bool{
+    "PROCEDURE": true, "FUNCTION": true, "TRIGGER": true, "PACKAGE": true, "EVENT": true,
+}
+
+// plainCreateWords name the objects whose CREATE statement has no procedural
+// body, so a routine word after them is only a name
+var plainCreateWords = map[string]bool{
+    "TABLE": true, "VIEW": true, "INDEX": true, "UNIQUE": true, "SEQUENCE": true,
+    "SCHEMA": true, "DATABASE": true, "SYNONYM": true, "USER": true, "ROLE": true,
+}
+
+// endWords follow the END that closes a control statement not counted as a block
+var endWords = map[string]bool{"IF": true, "LOOP": true, "WHILE": true, "REPEAT": true}
+
+// splitStatements cuts a script into statements the way the client tools of
+// each engine do. Semicolons end statements unless they are inside a literal,
+// a quoted identifier, a comment, a dollar-quoted body or the BEGIN ... END of
+// a routine. MySQL scripts may change the delimiter with DELIMITER lines, SQL
+// Server scripts are cut into batches at GO lines and Oracle PL/SQL blocks end
+// at a line holding only "/". Empty statements are dropped and the trailing
+// delimiter is removed.
+func splitStatements(d Dialect, script string) []string {
+    s := &scriptSplitter{script: script, delimiter: ";", syntax: syntaxFor(d)}
+    switch d.(type) {
+    case mysqlDialect:
+        s.delimiters = true
+    case sqlserverDialect:
+        s.batches = true
+    case oracleDialect:
+        s.oracle = true
+    }
+    s.split()
+    return s.statements
+}
+
+type scriptSplitter struct {
+    script     string
+    syntax     sqlSyntax
+    delimiter  string
+    delimiters bool // DELIMITER lines are honoured
+    batches    bool // GO lines end batches and semicolons do not split
+    oracle     bool // "/" lines end PL/SQL blocks
+    statements []string
+    start      int
+    words      []string // the first words of the current statement
+    routine    bool     // the current statement is a block or has a procedural body
+    depth      int      // BEGIN/CASE ... END nesting inside a routine
+    afterEnd   bool     // the previous word was END, as in END CASE
+}
+
+func (s *scriptSplitter) split() {
+    script := s.script
+    n := len(script)
+    for i := 0; i < n; {
+        if i == 0 || script[i-1] == '\n' {
+            if next, ok := s.lineCommand(i); ok {
+                i = next
+                continue
+            }
+        }
+
+        if s.delimiter != ";" && strings.HasPrefix(script[i:], s.delimiter) {
+            s.flush(i)
+            i += len(s.delimiter)
+            s.start = i
+            continue
+        }
+        if sp := s.syntax.span(script, i); sp.kind != noSpan {
+            // Un comentario de línea termina antes del salto de línea
+            i = sp.end
+            continue
+        }
+
+        c := script[i]
+        switch {
+        case s.syntax.wordByte(c):
+            j := i + 1
+            for j < n && s.syntax.wordByte(script[j]) && (s.delimiter == ";" || !strings.HasPrefix(script[j:], s.delimiter)) {
+                j++
+            }
+            s.word(strings.ToUpper(script[i:j]), j)
+            i = j
+        case c == ';' && s.delimiter == ";" && !s.batches:
+            if s.routine && (s.oracle || s.depth > 0) {
+                // Punto y coma dentro del cuerpo de la rutina
+                i++
+                continue
+            }
+            s.flush(i)
+            i++
+            s.start = i
+        default:
+            i++
+        }
+    }
+    s.flush(n)
+}
+
+// lineCommand handles the client commands that take a whole line: GO, "/"
+// and DELIMITER. It returns where the next line starts.
+func (s *scriptSplitter) lineCommand(i int) (int, bool) {
+    end := strings.IndexByte(s.script[i:], '\n')
+    next := len(s.script)
+    if end >= 0 {
+        end += i
+        next = end + 1
+    } else {
+        end = next
+    }
+    line := s.script[i:end]
+
+    switch {
+    case s.batches:
+        m := goBatch.FindStringSubmatch(line)
+        if m == nil {
+            return 0, false
+        }
+        count := 1
+        if m[1] != "" {
+            count, _ = strconv.Atoi(m[1])
+        }
+        for k := 0; k < count; k++ {
+            s.flush(i)
+        }
+    case s.oracle:
+        if !slashLine.MatchString(line) {
+            return 0, false
+        }
+        s.flush(i)
+    case s.delimiters:
+        m := delimiterLine.FindStringSubmatch(line)
+        if m == nil {
+            return 0, false
+        }
+        s.flush(i)
+        s.delimiter = m[1]
+    default:
+        return 0, false
+    }
+    s.start = next
+    return next, true
+}
+
+// word tracks the words that tell whether the statement is a routine and,
+// inside one, how deep its blocks are nested. next is where the word ends.
+func (s *scriptSplitter) word(w string, next int) {
+    if len(s.words) < 8 {
+        s.words = append(s.words, w)
+        if !s.routine {
+            s.detectRoutine()
+            if s.routine {
+                return
+            }
+        }
+    }
+    if !s.routine {
+        return
+    }
+    if s.afterEnd {
+        // La palabra que sigue a END no abre un bloque
+        s.afterEnd = false
+        return
+    }
+
+    switch w {
+    case "BEGIN", "CASE":
+        s.depth++
+    case "END":
+        following := s.nextWord(next)
+        s.afterEnd = following != ""
+        if !endWords[following] && s.depth > 0 {
+            s.depth--
+        }
+    }
+}
+
+func (s *scriptSplitter) detectRoutine() {
+    words := s.words
+    switch words[0] {
+    case "BEGIN":
+        // BEGIN seguido de una palabra que no es de transacción abre un bloque
+        if len(words) == 2 && !transactionWords[words[1]] {
+            s.routine, s.depth = true, 1
+        }
+    case "DECLARE":
+        s.routine = s.oracle
+    case "CREATE":
+        if len(words) > 6 {
+            return
+        }
+        for _, w := range words[1:] {
+            if plainCreateWords[w] {
+                return
+            }
+        }
+        last := words[len(words)-1]
+        s.routine = routineWords[last] || (s.oracle && last == "TYPE")
+    }
+}
+
+// nextWord returns the word after position i in upper case, or "" when
+// something else comes first
+func (s *scriptSplitter) nextWord(i int) string {
+    for i < len(s.script) && (s.script[i] == ' ' || s.script[i] == '\t' || s.script[i] == '\r' || s.script[i] == '\n') {
+        i++
+    }
+    j := i
+    for j < len(s.script) && isIdentChar(s.script[j]) {
+        j++
+    }
+    return strings.ToUpper(s.script[i:j])
+}
+
+// flush ends the current statement at end
+func (s *scriptSplitter) flush(end int) {
+    if stmt := strings.TrimSpace(s.script[s.start:end]); stripLeadingComments(s.syntax, stmt) != "" {
+        s.statements = append(s.statements, stmt)
+    }
+    s.words, s.routine, s.depth, s.afterEnd = nil, false, 0, false
+}
+
+// stripLeadingComments removes the comments and blanks that open stmt
+func stripLeadingComments(x sqlSyntax, stmt string) string {
+    for {
+        stmt = strings.TrimSpace(stmt)
+        if stmt == "" {
+            return ""
+        }
+        sp := x.span(stmt, 0)
+        if sp.kind != commentSpan {
+            return stmt
+        }
+        stmt = stmt[sp.end:]
+    }
+}
+
+// SQLrunScript opens a one-shot connection and runs the statements of script
+// in order, split as described for splitStatements. With inTransaction they
+// run in one transaction that is rolled back if a statement fails; otherwise
+// the statements before the failure stay applied. The statements after a
+// failure are not run. The result is a JSON array with the result or the
+// error of every statement run.
+func SQLrunScript(driver string, conexion string, script string, inTransaction bool) STRC.InternalResult {
+    ctx := context.Background()
+    d := dialectFor(driver)
+
+    db, err := sql.Open(d.DriverName(), conexion)
+    if err != nil {
+        return STRC.InternalResult{
+            Json:     createErrorJSON(fmt.Sprintf("Error al abrir conexión: %v", err)),
+            Is_error: 1,
+            Is_empty: 0,
+        }
+    }
+    defer db.Close()
+
+    if err := db.PingContext(ctx); err != nil {
+        return errorResult(ctx, d, "Error al conectar a la base de datos", err)
+    }
+
+    return runScript(ctx, db, nil, d, ENCODER.Strings, driver, splitStatements(d, script), inTransaction)
+}
+
+// SQLrunScriptOnLoad is SQLrunScript on the primary of a preloaded
+// connection. Every statement is checked against the read-only flag and the
+// allow-lists of the connector before the first one runs.
+func SQLrunScriptOnLoad(connector *Connector, script string, inTransaction bool) STRC.InternalResult {
+    ctx := context.Background()
+    statements := splitStatements(connector.dialect, script)
+    for _, stmt := range statements {
+        if err := connector.allows(stmt); err != nil {
+            return errorResult(ctx, connector.dialect, "", err)
+        }
+    }
+
+    db, err := connector.handle()
+    if err != nil {
+        return STRC.InternalResult{
+            Json:     createErrorJSON(err.Error()),
+            Is_error: 1,
+            Is_empty: 0,
+        }
+    }
+
+    return runScript(ctx, db, connector, connector.dialect, connector.outputMode(ctx), connector.driver, statements, inTransaction)
+}
+
+// runScript runs statements in order on a single connection of db and stops
+// at the first failure. connector is nil on the one-shot path.
+func runScript(ctx context.Context, db *sql.DB, connector *Connector, d Dialect, mode ENCODER.Mode, driver string, statements []string, inTransaction bool) STRC.InternalResult {
+    if len(statements) == 0 {
+        return STRC.InternalResult{
+            Json:     "[]",
+            Is_error: 0,
+            Is_empty: 1,
+        }
+    }
+
+    // Una sola conexión: las sentencias SET o USE afectan a las siguientes
+    conn, err := db.Conn(ctx)
+    if err != nil {
+        return errorResult(ctx, d, "Error al conectar a la base de datos", err)
+    }
+    defer conn.Close()
+
+    var q execer = conn
+    var tx *sql.Tx
+    if inTransaction {
+        var opts *sql.TxOptions
+        if connector != nil {
+            opts = connector.txOptions(sql.LevelDefault)
+        }
+        if tx, err = conn.BeginTx(ctx, opts); err != nil {
+            return errorResult(ctx, d, "error al iniciar transacción", err)
+        }
+        q = tx
+    }
+
+    hooks := hooksFor(connector)
+    results := make([]STRC.StatementResult, 0, len(statements))
+    var writes []string
+    failed := false
+    for _, stmt := range statements {
+        result := observe(ctx, hooks, driver, stmt, nil, func() STRC.InternalResult {
+            return runScriptStatement(ctx, q, connector, d, mode, stmt)
+        })
+
+        entry := STRC.StatementResult{Statement: stmt}
+        if result.Is_error != 0 {
+            entry.Error = json.RawMessage(result.Json)
+            results = append(results, entry)
+            failed = true
+            break
+        }
+        entry.Result = json.RawMessage(result.Json)
+        results = append(results, entry)
+        if !isReadStatement(d, stmt) {
+            writes = append(writes, stmt)
+        }
+    }
+
+    if tx != nil {
+        if failed {
+            tx.Rollback()
+            for i := range results[:len(results)-1] {
+                results[i].RolledBack = true
+            }
+            writes = nil
+        } else if err := tx.Commit(); err != nil {
+            return errorResult(ctx, d, "error al confirmar transacción", err)
+        }
+    }
+    if connector != nil {
+        for _, stmt := range writes {
+            connector.cachedResults().invalidateWrite(d, stmt)
+        }
+    }
+
+    var buf bytes.Buffer
+    encoder := json.NewEncoder(&buf)
+    encoder.SetEscapeHTML(false)
+    encoder.Encode(results)
+    return STRC.InternalResult{
+        Json:     strings.TrimSuffix(buf.String(), "\n"),
+        Is_error: boolToInt(failed),
+        Is_empty: 0,
+    }
+}
+
+// runScriptStatement runs one statement of a script, bounded by the
+// statement timeout of the connector when there is one
+func runScriptStatement(ctx context.Context, q execer, connector *Connector, d Dialect, mode ENCODER.Mode, stmt string) STRC.InternalResult {
+    if connector == nil {
+        return runStatement(ctx, q, d, mode, stmt)
+    }
+
+    ctx, cancel := statementContext(ctx, connector)
+    defer cancel()
+
+    start := time.Now()
+    result := runStatement(ctx, q, d, mode, stmt)
+    connector.stats.record(time.Since(start), result.Is_error != 0)
+    return result
+}
@@ -0,0 +1,109 @@
+package db
+
+import (
+    "reflect"
+    "testing"
+)
+
+func TestSplitStatements(t *testing.T) {
+    tests := []struct {
+        name   string
+        driver string
+        script string
+        want   []string
+    }{
+        {
+            name:   "semicolons",
+            driver: "sqlite3",
+            script: "CREATE TABLE t (id INT);\nINSERT INTO t VALUES (1);\n\nSELECT * FROM t;",
+            want:   []string{"CREATE TABLE t (id INT)", "INSERT INTO t VALUES (1)", "SELECT * FROM t"},
+        },
+        {
+            name:   "literals, identifiers and comments",
+            driver: "sqlite3",
+            script: "INSERT INTO t VALUES ('a;b', \"c;d\", [e;f]); -- x;y\n/* z; */ SELECT 1;",
+            want:   []string{"INSERT INTO t VALUES ('a;b', \"c;d\", [e;f])", "-- x;y\n/* z; */ SELECT 1"},
+        },
+        {
+            name:   "comment-only statements are dropped",
+            driver: "sqlite3",
+            script: "SELECT 1;\n-- fin\n;/* nada */",
+            want:   []string{"SELECT 1"},
+        },
+        {
+            name:   "sqlite trigger",
+            driver: "sqlite3",
+            script: "CREATE TRIGGER tr AFTER INSERT ON t BEGIN UPDATE t SET n = 1; DELETE FROM u; END;\nSELECT 1;",
+            want:   []string{"CREATE TRIGGER tr AFTER INSERT ON t BEGIN UPDATE t SET n = 1; DELETE FROM u; END", "SELECT 1"},
+        },
+        {
+            name:   "mysql backslash escape",
+            driver: "mysql",
+            script: "INSERT INTO t VALUES ('O\\'Brien');\nINSERT INTO t VALUES ('x');\nDELETE FROM t;",
+            want:   []string{"INSERT INTO t VALUES ('O\\'Brien')", "INSERT INTO t VALUES ('x')", "DELETE FROM t"},
+        },
+        {
+            name:   "mysql hash comment",
+            driver: "mysql",
+            script: "# it's a comment\nSELECT 1;\nSELECT 2;",
+            want:   []string{"# it's a comment\nSELECT 1", "SELECT 2"},
+        },
+        {
+            name:   "mysql double dash needs a blank",
+            driver: "mysql",
+            script: "SELECT 1--1;\nSELECT 2; -- it's\nSELECT 3;",
+            want:   []string{"SELECT 1--1", "SELECT 2", "-- it's\nSELECT 3"},
+        },
+        {
+            name:   "mysql delimiter",
+            driver: "mysql",
+            script: "DELIMITER //\nCREATE PROCEDURE p() BEGIN SELECT 1; SELECT 2; END//\nDELIMITER ;\nCALL p();",
+            want:   []string{"CREATE PROCEDURE p() BEGIN SELECT 1; SELECT 2; END", "CALL p()"},
+        },
+        {
+            name:   "mysql routine without delimiter",
+            driver: "mysql",
+            script: "CREATE PROCEDURE p() BEGIN CASE WHEN 1 THEN SELECT 1; END CASE; IF 1 THEN SELECT 2; END IF; END;\nSELECT 3;",
+            want:   []string{"CREATE PROCEDURE p() BEGIN CASE WHEN 1 THEN SELECT 1; END CASE; IF 1 THEN SELECT 2; END IF; END", "SELECT 3"},
+        },
+        {
+            name:   "postgres dollar quotes",
+            driver: "postgres",
+            script: "CREATE FUNCTION f() RETURNS int AS $$ BEGIN RETURN 1; END; $$ LANGUAGE plpgsql;\nSELECT f();",
+            want:   []string{"CREATE FUNCTION f() RETURNS int AS $$ BEGIN RETURN 1; END; $$ LANGUAGE plpgsql", "SELECT f()"},
+        },
+        {
+            name:   "postgres escape string and nested comment",
+            driver: "postgres",
+            script: "SELECT E'it\\'s;';\n/* a /* b; */ c; */ SELECT 2;",
+            want:   []string{"SELECT E'it\\'s;'", "/* a /* b; */ c; */ SELECT 2"},
+        },
+        {
+            name:   "postgres brackets are subscripts",
+            driver: "postgres",
+            script: "SELECT a[1] FROM t;\nSELECT ']';",
+            want:   []string{"SELECT a[1] FROM t", "SELECT ']'"},
+        },
+        {
+            name:   "sqlserver batches",
+            driver: "sqlserver",
+            script: "CREATE TABLE [a;b] (id INT);\nINSERT INTO [a;b] VALUES (1);\nGO\nSELECT 1\ngo 2\n",
+            want:   []string{"CREATE TABLE [a;b] (id INT);\nINSERT INTO [a;b] VALUES (1);", "SELECT 1", "SELECT 1"},
+        },
+        {
+            name:   "oracle plsql blocks",
+            driver: "oracle",
+            script: "BEGIN\n  UPDATE t SET n = 1;\nEND;\n/\nSELECT q'[it's; fine]' FROM dual;\nDECLARE x NUMBER; BEGIN x := 1; END;\n/\n",
+            want:   []string{"BEGIN\n  UPDATE t SET n = 1;\nEND;", "SELECT q'[it's; fine]' FROM dual", "DECLARE x NUMBER; BEGIN x := 1; END;"},
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got := splitStatements(dialectFor(tt.driver), tt.script)
+            if !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("splitStatements(%q)\n got %q\nwant %q", tt.script, got, tt.want)
+            }
+        })
+    }
+}
@@ -0,0 +1,232 @@
+package db
+
+import (
+    "container/list"
+    "context"
+    "database/sql"
+    "sync"
+
+    STRC "github.com/IngenieroRicardo/db/STRUCTURES"
+)
+
+// DefaultStatementCacheSize is the number of prepared statements a Connector
+// keeps by default. SetStatementCacheSize changes it per connector.
+const DefaultStatementCacheSize = 100
+
+// stmtCache is an LRU of prepared statements keyed by handle and query text
+type stmtCache struct {
+    mu       sync.Mutex
+    dialect  Dialect
+    capacity int
+    order    *list.List // *cachedStmt, most recently used first
+    byQuery  map[stmtKey]*list.Element
+    hits     int64
+    misses   int64
+}
+
+// stmtKey ties a statement to the handle that prepared it, so a statement
+// prepared on a handle that is replaced meanwhile is never handed out for
+// the new one
+type stmtKey struct {
+    db    *sql.DB
+    query string
+}
+
+// cachedStmt counts the callers running the statement so an evicted
+// statement is closed only once the last of them is done
+type cachedStmt struct {
+    key     stmtKey
+    stmt    *sql.Stmt
+    refs    int
+    evicted bool
+}
+
+func newStmtCache(d Dialect, capacity int) *stmtCache {
+    return &stmtCache{
+        dialect:  d,
+        capacity: capacity,
+        order:    list.New(),
+        byQuery:  make(map[stmtKey]*list.Element),
+    }
+}
+
+// acquire returns the prepared statement for query, preparing it on a miss.
+// It returns nil when the cache is disabled, the statement is not worth
+// caching or the driver cannot prepare it; the caller then runs query directly.
+func (c *stmtCache) acquire(ctx context.Context, db *sql.DB, query string) *cachedStmt {
+    if !cacheable(c.dialect, query) {
+        return nil
+    }
+
+    key := stmtKey{db: db, query: query}
+    c.mu.Lock()
+    if c.capacity <= 0 {
+        c.mu.Unlock()
+        return nil
+    }
+    if e, ok := c.byQuery[key]; ok {
+        c.hits++
+        c.order.MoveToFront(e)
+        s := e.Value.(*cachedStmt)
+        s.refs++
+        c.mu.Unlock()
+        return s
+    }
+    c.misses++
+    c.mu.Unlock()
+
+    // Preparamos fuera del lock: puede requerir un viaje al servidor
+    stmt, err := db.PrepareContext(ctx, query)
+    if err != nil {
+        return nil
+    }
+
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    if e, ok := c.byQuery[key]; ok {
+        // Otra goroutine lo preparó mientras tanto
+        stmt.Close()
+        s := e.Value.(*cachedStmt)
+        s.refs++
+        return s
+    }
+    s := &cachedStmt{key: key, stmt: stmt, refs: 1}
+    if c.capacity <= 0 {
+        s.evicted = true
+        return s
+    }
+    c.byQuery[key] = c.order.PushFront(s)
+    for c.order.Len() > c.capacity {
+        c.evict(c.order.Back().Value.(*cachedStmt))
+    }
+    return s
+}
+
+// release ends a use of s started by acquire
+func (c *stmtCache) release(s *cachedStmt) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    s.refs--
+    if s.evicted && s.refs == 0 {
+        s.stmt.Close()
+    }
+}
+
+// discard removes s after a failed execution so the next call prepares it again
+func (c *stmtCache) discard(s *cachedStmt) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    if e, ok := c.byQuery[s.key]; ok && e.Value.(*cachedStmt) == s {
+        c.evict(s)
+    }
+}
+
+// evict must be called with c.mu held
+func (c *stmtCache) evict(s *cachedStmt) {
+    if e, ok := c.byQuery[s.key]; ok {
+        c.order.Remove(e)
+        delete(c.byQuery, s.key)
+    }
+    s.evicted = true
+    if s.refs == 0 {
+        s.stmt.Close()
+    }
+}
+
+// resize changes the capacity, evicting the least recently used statements
+func (c *stmtCache) resize(capacity int) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    c.capacity = capacity
+    for c.order.Len() > 0 && c.order.Len() > capacity {
+        c.evict(c.order.Back().Value.(*cachedStmt))
+    }
+}
+
+// clear closes every cached statement and keeps the capacity
+func (c *stmtCache) clear() {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    for c.order.Len() > 0 {
+        c.evict(c.order.Back().Value.(*cachedStmt))
+    }
+}
+
+func (c *stmtCache) stats() STRC.StatementCacheStats {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    return STRC.StatementCacheStats{
+        Capacity: c.capacity,
+        Size:     c.order.Len(),
+        Hits:     c.hits,
+        Misses:   c.misses,
+    }
+}
+
+// cacheable limits the cache to the statements that are run over and over;
+// DDL and session commands are sent as they are
+func cacheable(d Dialect, query string) bool {
+    statements := ClassifyStatements(d, query)
+    if len(statements) != 1 {
+        return false
+    }
+    switch statements[0].Verb {
+    case "SELECT", "INSERT", "UPDATE", "DELETE", "REPLACE", "MERGE", "UPSERT":
+        return true
+    }
+    return false
+}
+
+// cachedConn runs statements of a Connector through its statement cache
+type cachedConn struct {
+    db    *sql.DB
+    cache *stmtCache
+}
+
+func (c cachedConn) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+    s := c.cache.acquire(ctx, c.db, query)
+    if s == nil {
+        return c.db.QueryContext(ctx, query, args...)
+    }
+    defer c.cache.release(s)
+
+    rows, err := s.stmt.QueryContext(ctx, args...)
+    if err != nil {
+        c.cache.discard(s)
+    }
+    return rows, err
+}
+
+func (c cachedConn) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+    s := c.cache.acquire(ctx, c.db, query)
+    if s == nil {
+        return c.db.ExecContext(ctx, query, args...)
+    }
+    defer c.cache.release(s)
+
+    res, err := s.stmt.ExecContext(ctx, args...)
+    if err != nil {
+        c.cache.discard(s)
+    }
+    return res, err
+}
+
+func (c cachedConn) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+    return c.db.PrepareContext(ctx, query)
+}
+
+func (c cachedConn) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+    return c.db.BeginTx(ctx, opts)
+}
+
+// SetStatementCacheSize sets how many prepared statements the connector keeps.
+// Zero or less disables the cache and closes the statements it holds.
+func SetStatementCacheSize(connector *Connector, size int) {
+    connector.stmts.resize(size)
+}
+
+// StatementCacheStats reports the size and the hit/miss counters of the
+// connector statement cache
+func StatementCacheStats(connector *Connector) STRC.StatementCacheStats {
+    return connector.stmts.stats()
+}
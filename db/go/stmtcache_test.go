@@ -0,0 +1,120 @@
+package db
+
+import (
+    "context"
+    "database/sql"
+    "testing"
+)
+
+func openMemory(t *testing.T) *sql.DB {
+    t.Helper()
+    db, err := sql.Open("sqlite3", ":memory:")
+    if err != nil {
+        t.Fatal(err)
+    }
+    db.SetMaxOpenConns(1)
+    t.Cleanup(func() { db.Close() })
+    return db
+}
+
+func TestCacheable(t *testing.T) {
+    tests := []struct {
+        query string
+        want  bool
+    }{
+        {"SELECT * FROM t WHERE id = ?", true},
+        {"insert into t values (?)", true},
+        {"UPDATE t SET a = ?", true},
+        {"DELETE FROM t", true},
+        {"CREATE TABLE t (id INT)", false},
+        {"PRAGMA foreign_keys = ON", false},
+        {"SELECT 1; SELECT 2", false},
+        {"", false},
+    }
+
+    for _, tt := range tests {
+        if got := cacheable(dialectFor("sqlite3"), tt.query); got != tt.want {
+            t.Errorf("cacheable(%q) = %v, want %v", tt.query, got, tt.want)
+        }
+    }
+}
+
+func TestStmtCacheLRU(t *testing.T) {
+    db := openMemory(t)
+    c := newStmtCache(dialectFor("sqlite3"), 2)
+    ctx := context.Background()
+
+    steps := []struct {
+        query string
+        hit   bool
+    }{
+        {"SELECT 1", false},
+        {"SELECT 2", false},
+        {"SELECT 1", true},
+        {"SELECT 3", false}, // evicts SELECT 2, the least recently used
+        {"SELECT 1", true},
+        {"SELECT 2", false},
+    }
+    for i, step := range steps {
+        before := c.stats().Hits
+        s := c.acquire(ctx, db, step.query)
+        if s == nil {
+            t.Fatalf("step %d: acquire(%q) = nil", i, step.query)
+        }
+        c.release(s)
+        if hit := c.stats().Hits > before; hit != step.hit {
+            t.Errorf("step %d: acquire(%q) hit = %v, want %v", i, step.query, hit, step.hit)
+        }
+    }
+
+    stats := c.stats()
+    if stats.Size != 2 || stats.Hits != 2 || stats.Misses != 4 {
+        t.Errorf("stats = %+v, want size 2, 2 hits and 4 misses", stats)
+    }
+}
+
+func TestStmtCacheEvictedInUse(t *testing.T) {
+    db := openMemory(t)
+    c := newStmtCache(dialectFor("sqlite3"), 1)
+    ctx := context.Background()
+
+    s := c.acquire(ctx, db, "SELECT 1")
+    c.resize(0)
+    if c.stats().Size != 0 {
+        t.Fatalf("resize(0) kept %d statements", c.stats().Size)
+    }
+    // Sigue abierto mientras quien lo adquirió no lo libere
+    if _, err := s.stmt.ExecContext(ctx); err != nil {
+        t.Fatalf("evicted statement closed while in use: %v", err)
+    }
+    c.release(s)
+    if _, err := s.stmt.ExecContext(ctx); err == nil {
+        t.Error("evicted statement still open after release")
+    }
+
+    if s := c.acquire(ctx, db, "SELECT 1"); s != nil {
+        t.Error("acquire with the cache disabled returned a statement")
+    }
+}
+
+func TestStmtCacheKeyedByHandle(t *testing.T) {
+    oldDB, newDB := openMemory(t), openMemory(t)
+    c := newStmtCache(dialectFor("sqlite3"), 10)
+    ctx := context.Background()
+
+    old := c.acquire(ctx, oldDB, "SELECT 1")
+    c.release(old)
+    c.clear()
+    // Preparado en el handle anterior después de clear, como en una carrera con drop
+    late := c.acquire(ctx, oldDB, "SELECT 1")
+    c.release(late)
+
+    s := c.acquire(ctx, newDB, "SELECT 1")
+    defer c.release(s)
+    if s == late {
+        t.Fatal("the statement prepared on the old handle was handed out for the new one")
+    }
+    if stats := c.stats(); stats.Hits != 0 || stats.Misses != 3 {
+        t.Errorf("stats = %+v, want 0 hits and 3 misses", stats)
+    }
+}
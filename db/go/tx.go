@@ -0,0 +1,197 @@
+package db
+
+import (
+    "context"
+    "database/sql"
+    "fmt"
+    "regexp"
+    "strings"
+
+    ARGS "github.com/IngenieroRicardo/db/ARGS"
+    STRC "github.com/IngenieroRicardo/db/STRUCTURES"
+)
+
+// Transaction is an explicit unit of work opened on a Connector
+type Transaction struct {
+    tx        *sql.Tx
+    connector *Connector
+    writes    []string // invalidate the connector result cache on commit
+}
+
+// SavepointDialect is implemented by dialects whose savepoint syntax differs
+// from the standard SAVEPOINT / ROLLBACK TO SAVEPOINT / RELEASE SAVEPOINT.
+// ReleaseSavepointSQL may return "" when the engine has no release statement.
+type SavepointDialect interface {
+    SavepointSQL(name string) string
+    RollbackToSavepointSQL(name string) string
+    ReleaseSavepointSQL(name string) string
+}
+
+var savepointName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// BeginTx starts a transaction on the connector. isolationLevel accepts "",
+// "READ UNCOMMITTED", "READ COMMITTED", "WRITE COMMITTED", "REPEATABLE READ",
+// "SNAPSHOT", "SERIALIZABLE" or "LINEARIZABLE" ("" uses the engine default).
+func BeginTx(connector *Connector, isolationLevel string) (*Transaction, error) {
+    level, err := parseIsolationLevel(isolationLevel)
+    if err != nil {
+        return nil, err
+    }
+
+    db, err := connector.handle()
+    if err != nil {
+        return nil, err
+    }
+
+    tx, err := db.BeginTx(context.Background(), connector.txOptions(level))
+    if err != nil {
+        return nil, fmt.Errorf("error al iniciar transacción: %w", err)
+    }
+
+    return &Transaction{tx: tx, connector: connector}, nil
+}
+
+// SQLrunOnTx executes a query inside the transaction
+func SQLrunOnTx(tx *Transaction, query string, args ...string) STRC.InternalResult {
+    return SQLrunOnTxContext(context.Background(), tx, query, args...)
+}
+
+// SQLrunOnTxContext is SQLrunOnTx bounded by ctx and the connector statement timeout
+func SQLrunOnTxContext(ctx context.Context, tx *Transaction, query string, args ...string) STRC.InternalResult {
+    goArgs, err := ARGS.Parse(args)
+    if err != nil {
+        return STRC.InternalResult{
+            Json:     createErrorJSON(err.Error()),
+            Is_error: 1,
+            Is_empty: 0,
+        }
+    }
+
+    ctx, cancel := statementContext(ctx, tx.connector)
+    defer cancel()
+
+    result := observe(ctx, hooksFor(tx.connector), tx.connector.driver, query, goArgs, func() STRC.InternalResult {
+        if err := tx.connector.allows(query); err != nil {
+            return errorResult(ctx, tx.connector.dialect, "", err)
+        }
+        return runOnConn(ctx, tx.tx, tx.connector.dialect, tx.connector.outputMode(ctx), query, goArgs...)
+    })
+    if result.Is_error == 0 && !isReadStatement(tx.connector.dialect, query) {
+        tx.writes = append(tx.writes, query)
+    }
+    return result
+}
+
+// Commit confirms every statement run on the transaction
+func Commit(tx *Transaction) error {
+    if err := tx.tx.Commit(); err != nil {
+        return fmt.Errorf("error al confirmar transacción: %w", err)
+    }
+    for _, query := range tx.writes {
+        tx.connector.cachedResults().invalidateWrite(tx.connector.dialect, query)
+    }
+    return nil
+}
+
+// Rollback discards every statement run on the transaction
+func Rollback(tx *Transaction) error {
+    if err := tx.tx.Rollback(); err != nil {
+        return fmt.Errorf("error al revertir transacción: %w", err)
+    }
+    return nil
+}
+
+// Savepoint marks a point inside the transaction that can be rolled back to
+func Savepoint(tx *Transaction, name string) error {
+    if !savepointName.MatchString(name) {
+        return fmt.Errorf("nombre de savepoint inválido: %s", name)
+    }
+    stmt := "SAVEPOINT " + name
+    if sd, ok := tx.connector.dialect.(SavepointDialect); ok {
+        stmt = sd.SavepointSQL(name)
+    }
+    return execSavepoint(tx, stmt)
+}
+
+// RollbackToSavepoint undoes the work done after the savepoint, keeping the transaction open
+func RollbackToSavepoint(tx *Transaction, name string) error {
+    if !savepointName.MatchString(name) {
+        return fmt.Errorf("nombre de savepoint inválido: %s", name)
+    }
+    stmt := "ROLLBACK TO SAVEPOINT " + name
+    if sd, ok := tx.connector.dialect.(SavepointDialect); ok {
+        stmt = sd.RollbackToSavepointSQL(name)
+    }
+    return execSavepoint(tx, stmt)
+}
+
+// ReleaseSavepoint forgets a savepoint, keeping the work done after it
+func ReleaseSavepoint(tx *Transaction, name string) error {
+    if !savepointName.MatchString(name) {
+        return fmt.Errorf("nombre de savepoint inválido: %s", name)
+    }
+    stmt := "RELEASE SAVEPOINT " + name
+    if sd, ok := tx.connector.dialect.(SavepointDialect); ok {
+        stmt = sd.ReleaseSavepointSQL(name)
+    }
+    if stmt == "" {
+        return nil
+    }
+    return execSavepoint(tx, stmt)
+}
+
+func execSavepoint(tx *Transaction, stmt string) error {
+    if _, err := tx.tx.Exec(stmt); err != nil {
+        return fmt.Errorf("error en savepoint: %w", err)
+    }
+    return nil
+}
+
+func parseIsolationLevel(level string) (sql.IsolationLevel, error) {
+    normalized := strings.ToUpper(strings.TrimSpace(strings.ReplaceAll(level, "_", " ")))
+    switch normalized {
+    case "", "DEFAULT":
+        return sql.LevelDefault, nil
+    case "READ UNCOMMITTED":
+        return sql.LevelReadUncommitted, nil
+    case "READ COMMITTED":
+        return sql.LevelReadCommitted, nil
+    case "WRITE COMMITTED":
+        return sql.LevelWriteCommitted, nil
+    case "REPEATABLE READ":
+        return sql.LevelRepeatableRead, nil
+    case "SNAPSHOT":
+        return sql.LevelSnapshot, nil
+    case "SERIALIZABLE":
+        return sql.LevelSerializable, nil
+    case "LINEARIZABLE":
+        return sql.LevelLinearizable, nil
+    }
+    return sql.LevelDefault, fmt.Errorf("nivel de aislamiento desconocido: %s", level)
+}
+
+// SQL Server names savepoints with SAVE TRANSACTION and has no release statement
+func (sqlserverDialect) SavepointSQL(name string) string {
+    return "SAVE TRANSACTION " + name
+}
+
+func (sqlserverDialect) RollbackToSavepointSQL(name string) string {
+    return "ROLLBACK TRANSACTION " + name
+}
+
+func (sqlserverDialect) ReleaseSavepointSQL(name string) string {
+    return ""
+}
+
+// Oracle supports SAVEPOINT and ROLLBACK TO SAVEPOINT but not RELEASE
+func (oracleDialect) SavepointSQL(name string) string {
+    return "SAVEPOINT " + name
+}
+
+func (oracleDialect) RollbackToSavepointSQL(name string) string {
+    return "ROLLBACK TO SAVEPOINT " + name
+}
+
+func (oracleDialect) ReleaseSavepointSQL(name string) string {
+    return ""
+}
@@ -2,10 +2,12 @@ package file
 
 import (
 	"encoding/base64"
+	"encoding/csv"
 	"io/ioutil"
 	"net/http"
 	"strings"
 	"os"
+	"path/filepath"
 )
 
 func WBFile(b64Str, outputPath string) error {
@@ -36,10 +38,30 @@ func RTFile(inputPath string) string {
 	return string(data)
 }
 
+// RCSVFile reads a CSV file and returns its records, the header included
+func RCSVFile(inputPath string) ([][]string, error) {
+	f, err := os.Open(inputPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return csv.NewReader(f).ReadAll()
+}
+
 func CreateDir(path string) error {
 	return os.MkdirAll(path, 0755)
 }
 
+// CreateFile opens outputPath for writing, creating its directory if needed
+// and truncating the file if it exists
+func CreateFile(outputPath string) (*os.File, error) {
+	if err := CreateDir(filepath.Dir(outputPath)); err != nil {
+		return nil, err
+	}
+	return os.OpenFile(outputPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+}
+
 func PathExists(path string) bool {
 	_, err := os.Stat(path)
 	if err == nil {